@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/atakanaydinbas/HTTP-Header-Security-Analyzer/internal"
+)
+
+// CLI exit codes
+const (
+	exitOK           = 0
+	exitPolicyFailed = 1
+	exitError        = 2
+)
+
+// cliOptions are the command-line flags used when running without the server
+type cliOptions struct {
+	URL      string
+	Strict   bool
+	MinGrade string
+	Format   string
+}
+
+// runCLI analyzes a single URL, prints the result to stdout and
+// returns the process exit code
+func runCLI(opts cliOptions) int {
+	if opts.MinGrade != "" && !internal.ValidGrade(opts.MinGrade) {
+		fmt.Fprintln(os.Stderr, "Invalid -min-grade: must be one of A, B, C, D, F")
+		return exitError
+	}
+
+	if !internal.ValidFormat(opts.Format) {
+		fmt.Fprintln(os.Stderr, "Invalid -format: must be one of json, text, markdown")
+		return exitError
+	}
+
+	result, err := internal.AnalyzeURL(opts.URL)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Failed to analyze URL:", err)
+		return exitError
+	}
+
+	output, err := internal.Format(result, opts.Format)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Failed to format result:", err)
+		return exitError
+	}
+	fmt.Print(output)
+
+	if opts.Strict {
+		if failed := result.FailedCriticalHeaders(); len(failed) > 0 {
+			for _, name := range failed {
+				fmt.Fprintf(os.Stderr, "strict: critical header %s is missing or weak\n", name)
+			}
+			return exitPolicyFailed
+		}
+	}
+
+	// Grades sort alphabetically from best to worst
+	if opts.MinGrade != "" && result.Grade > strings.TrimRight(opts.MinGrade, "+-") {
+		fmt.Fprintf(os.Stderr, "min-grade: grade %s is below %s\n", result.Grade, opts.MinGrade)
+		return exitPolicyFailed
+	}
+
+	return exitOK
+}
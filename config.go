@@ -0,0 +1,358 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/atakanaydinbas/HTTP-Header-Security-Analyzer/internal"
+)
+
+// loadConfig builds the analyzer configuration from environment variables,
+// falling back to the built-in defaults for anything that is not set
+func loadConfig() (internal.Config, error) {
+	cfg := internal.DefaultConfig()
+
+	if v := os.Getenv("CRITICAL_PENALTY_MULTIPLIER"); v != "" {
+		multiplier, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return cfg, fmt.Errorf("invalid CRITICAL_PENALTY_MULTIPLIER %q: %w", v, err)
+		}
+		cfg.CriticalPenaltyMultiplier = multiplier
+	}
+
+	if v := os.Getenv("SERVER_TIMING_SEVERITY"); v != "" {
+		cfg.ServerTimingSeverity = internal.Severity(v)
+	}
+
+	if v := os.Getenv("XFO_SAMEORIGIN_CREDIT"); v != "" {
+		credit, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return cfg, fmt.Errorf("invalid XFO_SAMEORIGIN_CREDIT %q: %w", v, err)
+		}
+		cfg.XFrameSameOriginCredit = credit
+	}
+
+	if v := os.Getenv("HTTP_CLIENT_TIMEOUT"); v != "" {
+		timeout, err := time.ParseDuration(v)
+		if err != nil {
+			return cfg, fmt.Errorf("invalid HTTP_CLIENT_TIMEOUT %q: %w", v, err)
+		}
+		cfg.ClientTimeout = timeout
+	}
+
+	if v := os.Getenv("MAX_CONCURRENT_FETCHES"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil {
+			return cfg, fmt.Errorf("invalid MAX_CONCURRENT_FETCHES %q: %w", v, err)
+		}
+		cfg.MaxConcurrentFetches = limit
+	}
+
+	if v := os.Getenv("FETCH_QUEUE_TIMEOUT"); v != "" {
+		timeout, err := time.ParseDuration(v)
+		if err != nil {
+			return cfg, fmt.Errorf("invalid FETCH_QUEUE_TIMEOUT %q: %w", v, err)
+		}
+		cfg.FetchQueueTimeout = timeout
+	}
+
+	if v := os.Getenv("USER_AGENT"); v != "" {
+		cfg.UserAgent = v
+	}
+
+	cfg.SigningSecret = os.Getenv("RESULT_SIGNING_SECRET")
+
+	cfg.RawRequestAllowedHosts = splitList(os.Getenv("RAW_REQUEST_ALLOWED_HOSTS"))
+
+	for _, cidr := range splitList(os.Getenv("SSRF_ALLOWED_NETWORKS")) {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return cfg, fmt.Errorf("invalid SSRF_ALLOWED_NETWORKS entry %q: %w", cidr, err)
+		}
+		cfg.AllowedNetworks = append(cfg.AllowedNetworks, network)
+	}
+
+	if v, ok := os.LookupEnv("REDACTED_HEADERS"); ok {
+		cfg.RedactedHeaders = splitList(v)
+	}
+
+	if v, ok := os.LookupEnv("PERMISSIONS_POLICY_REQUIRED"); ok {
+		cfg.RequiredPermissions = splitList(v)
+	}
+
+	if v, ok := os.LookupEnv("REDACTED_PATTERNS"); ok {
+		patterns, err := parseRedactedPatterns(v)
+		if err != nil {
+			return cfg, err
+		}
+		cfg.RedactedPatterns = patterns
+	}
+
+	if path := os.Getenv("WEIGHTS_FILE"); path != "" {
+		weights, err := internal.LoadWeights(path)
+		if err != nil {
+			return cfg, fmt.Errorf("reading WEIGHTS_FILE: %w", err)
+		}
+		cfg.HeaderWeights = weights
+	}
+
+	thresholds, err := parseGradeThresholds(os.Getenv("GRADE_THRESHOLDS"), cfg.GradeThresholds)
+	if err != nil {
+		return cfg, err
+	}
+	cfg.GradeThresholds = thresholds
+
+	labels, err := parseGradeLabels(os.Getenv("GRADE_LABELS"))
+	if err != nil {
+		return cfg, err
+	}
+	cfg.GradeLabels = labels
+
+	return cfg, nil
+}
+
+// parseGradeLabels parses a comma-separated list of minScore=label pairs,
+// e.g. "80=pass,50=warn,0=fail"
+func parseGradeLabels(v string) ([]internal.GradeLabel, error) {
+	var labels []internal.GradeLabel
+	for _, pair := range splitList(v) {
+		minScore, label, ok := strings.Cut(pair, "=")
+		score, err := strconv.Atoi(strings.TrimSpace(minScore))
+		if !ok || err != nil {
+			return nil, fmt.Errorf("invalid GRADE_LABELS entry %q: expected minScore=label", pair)
+		}
+		labels = append(labels, internal.GradeLabel{MinScore: score, Label: strings.TrimSpace(label)})
+	}
+	return labels, nil
+}
+
+// parseGradeThresholds parses a comma-separated list of grade=minScore
+// pairs, e.g. "A=85,B=70", overriding the listed grades of defaults
+func parseGradeThresholds(v string, defaults internal.GradeThresholds) (internal.GradeThresholds, error) {
+	thresholds := defaults
+	for _, pair := range splitList(v) {
+		grade, minScore, ok := strings.Cut(pair, "=")
+		score, err := strconv.Atoi(strings.TrimSpace(minScore))
+		if !ok || err != nil {
+			return thresholds, fmt.Errorf("invalid GRADE_THRESHOLDS entry %q: expected grade=minScore", pair)
+		}
+		switch strings.ToUpper(strings.TrimSpace(grade)) {
+		case "A":
+			thresholds.A = score
+		case "B":
+			thresholds.B = score
+		case "C":
+			thresholds.C = score
+		case "D":
+			thresholds.D = score
+		default:
+			return thresholds, fmt.Errorf("invalid GRADE_THRESHOLDS entry %q: grade must be A, B, C or D", pair)
+		}
+	}
+	return thresholds, nil
+}
+
+// routePrefix reads the path every endpoint is mounted under, such as
+// "/security-analyzer", normalized to a leading and no trailing slash.
+// It is empty by default.
+func routePrefix() string {
+	prefix := strings.Trim(strings.TrimSpace(os.Getenv("ROUTE_PREFIX")), "/")
+	if prefix == "" {
+		return ""
+	}
+	return "/" + prefix
+}
+
+// loadReference reads the known-good reference server. It returns nil when
+// REFERENCE_URL is not set.
+func loadReference() (*internal.ReferenceServer, error) {
+	url := os.Getenv("REFERENCE_URL")
+	if url == "" {
+		return nil, nil
+	}
+
+	ttl := internal.DefaultReferenceTTL
+	if v := os.Getenv("REFERENCE_CACHE_TTL"); v != "" {
+		parsed, err := time.ParseDuration(v)
+		if err != nil || parsed < 0 {
+			return nil, fmt.Errorf("invalid REFERENCE_CACHE_TTL %q: must be a non-negative duration", v)
+		}
+		ttl = parsed
+	}
+	return internal.NewReferenceServer(url, ttl), nil
+}
+
+// resultStoreSize reads how many URLs the in-memory result store keeps.
+// A size of 0 disables the store.
+func resultStoreSize() (int, error) {
+	v := os.Getenv("RESULTS_STORE_SIZE")
+	if v == "" {
+		return internal.DefaultResultStoreSize, nil
+	}
+
+	size, err := strconv.Atoi(v)
+	if err != nil || size < 0 {
+		return 0, fmt.Errorf("invalid RESULTS_STORE_SIZE %q: must be a non-negative integer", v)
+	}
+	return size, nil
+}
+
+// resultCacheTTL reads how long /analyze results are reused. A TTL of 0
+// disables the cache.
+func resultCacheTTL() (time.Duration, error) {
+	v := os.Getenv("RESULT_CACHE_TTL")
+	if v == "" {
+		return internal.DefaultResultCacheTTL, nil
+	}
+
+	ttl, err := time.ParseDuration(v)
+	if err != nil || ttl < 0 {
+		return 0, fmt.Errorf("invalid RESULT_CACHE_TTL %q: must be a non-negative duration", v)
+	}
+	return ttl, nil
+}
+
+// Default per-client request limit
+const (
+	defaultRateLimitMax    = 60
+	defaultRateLimitWindow = time.Minute
+)
+
+// rateLimitConfig describes how many requests a client IP may make per window
+type rateLimitConfig struct {
+	Max    int
+	Window time.Duration
+}
+
+// loadRateLimit reads the per-client request limit. A Max of 0 disables it.
+func loadRateLimit() (rateLimitConfig, error) {
+	limit := rateLimitConfig{Max: defaultRateLimitMax, Window: defaultRateLimitWindow}
+
+	if v := os.Getenv("RATE_LIMIT_MAX"); v != "" {
+		requests, err := strconv.Atoi(v)
+		if err != nil || requests < 0 {
+			return limit, fmt.Errorf("invalid RATE_LIMIT_MAX %q: must be a non-negative integer", v)
+		}
+		limit.Max = requests
+	}
+
+	if v := os.Getenv("RATE_LIMIT_WINDOW"); v != "" {
+		window, err := time.ParseDuration(v)
+		if err != nil || window < time.Second {
+			return limit, fmt.Errorf("invalid RATE_LIMIT_WINDOW %q: must be a duration of at least 1s", v)
+		}
+		limit.Window = window
+	}
+
+	return limit, nil
+}
+
+// defaultShutdownTimeout is how long in-flight requests may take to finish
+// once the server is asked to stop
+const defaultShutdownTimeout = 30 * time.Second
+
+// shutdownTimeout reads how long a graceful shutdown waits for in-flight
+// requests before closing their connections
+func shutdownTimeout() (time.Duration, error) {
+	v := os.Getenv("SHUTDOWN_TIMEOUT")
+	if v == "" {
+		return defaultShutdownTimeout, nil
+	}
+
+	timeout, err := time.ParseDuration(v)
+	if err != nil || timeout <= 0 {
+		return 0, fmt.Errorf("invalid SHUTDOWN_TIMEOUT %q: must be a positive duration", v)
+	}
+	return timeout, nil
+}
+
+// scheduleConfig describes the optional internal rescan of an inventory
+type scheduleConfig struct {
+	URLs        []string
+	Interval    time.Duration
+	Concurrency int
+}
+
+// loadSchedule reads the rescan schedule. A zero Interval means the
+// scheduler is disabled.
+func loadSchedule() (scheduleConfig, error) {
+	schedule := scheduleConfig{Concurrency: internal.DefaultBatchConcurrency}
+
+	v := os.Getenv("SCAN_INTERVAL")
+	if v == "" {
+		return schedule, nil
+	}
+
+	interval, err := time.ParseDuration(v)
+	if err != nil || interval < 0 {
+		return schedule, fmt.Errorf("invalid SCAN_INTERVAL %q: must be a duration such as 1h", v)
+	}
+	if interval == 0 {
+		return schedule, nil
+	}
+	if interval < time.Minute {
+		return schedule, fmt.Errorf("invalid SCAN_INTERVAL %q: must be at least 1m", v)
+	}
+	schedule.Interval = interval
+
+	schedule.URLs = splitList(os.Getenv("INVENTORY_URLS"))
+	if path := os.Getenv("INVENTORY_FILE"); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return schedule, fmt.Errorf("reading INVENTORY_FILE: %w", err)
+		}
+		for _, line := range strings.Split(string(data), "\n") {
+			if line = strings.TrimSpace(line); line != "" && !strings.HasPrefix(line, "#") {
+				schedule.URLs = append(schedule.URLs, line)
+			}
+		}
+	}
+	if len(schedule.URLs) == 0 {
+		return schedule, fmt.Errorf("SCAN_INTERVAL is set but INVENTORY_URLS and INVENTORY_FILE list no URLs")
+	}
+
+	if v := os.Getenv("SCAN_CONCURRENCY"); v != "" {
+		concurrency, err := strconv.Atoi(v)
+		if err != nil || concurrency < 1 {
+			return schedule, fmt.Errorf("invalid SCAN_CONCURRENCY %q: must be a positive integer", v)
+		}
+		schedule.Concurrency = concurrency
+	}
+
+	return schedule, nil
+}
+
+// splitList parses a comma-separated environment value, dropping empty entries
+func splitList(v string) []string {
+	items := make([]string, 0)
+	for _, item := range strings.Split(v, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// parseRedactedPatterns compiles REDACTED_PATTERNS, one regular expression
+// per line. Lines are trimmed and blank ones skipped; commas are not
+// separators because they are common inside expressions, as in {1,3}.
+func parseRedactedPatterns(v string) ([]*regexp.Regexp, error) {
+	var patterns []*regexp.Regexp
+	for i, line := range strings.Split(v, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		pattern, err := regexp.Compile(line)
+		if err != nil {
+			return nil, fmt.Errorf("invalid REDACTED_PATTERNS line %d %q: %w", i+1, line, err)
+		}
+		patterns = append(patterns, pattern)
+	}
+	return patterns, nil
+}
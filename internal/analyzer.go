@@ -1,9 +1,15 @@
 package internal
 
 import (
+	"context"
 	"crypto/tls"
+	"errors"
+	"fmt"
+	"math"
+	"net"
 	"net/http"
-	"strings"
+	"net/http/httptrace"
+	"regexp"
 	"time"
 )
 
@@ -12,73 +18,363 @@ type SecurityHeader struct {
 	Present     bool     `json:"present"`
 	Description string   `json:"description"`
 	Weight      int      `json:"weight"`
+	Awarded     int      `json:"awarded"`
 	Aliases     []string `json:"aliases,omitempty"`
+	Value       string   `json:"value,omitempty"`
+	Values      []string `json:"values,omitempty"`
+	Severity    Severity `json:"severity,omitempty"`
+	Issues      []string `json:"issues,omitempty"`
+
+	// Notes are positive observations about the value, such as a CSP
+	// relying on nonces or hashes rather than host allowlists
+	Notes []string `json:"notes,omitempty"`
+
+	// Empty is set when the header is sent without a value; it then
+	// counts as present but earns no credit
+	Empty bool `json:"empty,omitempty"`
+
+	// Source is "trailer" when the header was delivered as an HTTP trailer
+	// rather than in the response headers
+	Source string `json:"source,omitempty"`
+
+	// MatchedName is the alias the header was found under, when it was not
+	// found under its own name
+	MatchedName string `json:"matchedName,omitempty"`
+
+	// Tier is the importance tier of a checked header
+	Tier SecurityHeaderTier `json:"tier,omitempty"`
+
+	// Penalty is the number of points a disclosure deducts from the score
+	Penalty int `json:"penalty,omitempty"`
+
+	// Directives are the parsed directives of a policy header, mapping
+	// each one to its value
+	Directives map[string]string `json:"directives,omitempty"`
+
+	// Remediation shows how to add the header when it is missing
+	Remediation *Remediation `json:"remediation,omitempty"`
+
+	// recommended is the value proposed for a checked header in remediation
+	// snippets and config diffs
+	recommended string
+}
+
+// tier returns the tier of a checked header, looking it up by name for
+// results recorded before tiers were reported
+func (h SecurityHeader) tier() SecurityHeaderTier {
+	if h.Tier != 0 {
+		return h.Tier
+	}
+	for _, header := range securityHeaders {
+		if header.Name == h.Name {
+			return header.Tier
+		}
+	}
+	return 0
 }
 
 type AnalysisResult struct {
-	Headers map[string]bool     `json:"headers"`
-	Score   int                 `json:"score"`
-	Grade   string              `json:"grade"`
-	Summary []SecurityHeader    `json:"summary"`
-	URL     string              `json:"url"`
+	Headers map[string]bool  `json:"headers"`
+	Score   int              `json:"score"`
+	Grade   string           `json:"grade" openapi:"required"`
+	Summary []SecurityHeader `json:"summary" openapi:"required"`
+	URL     string           `json:"url"`
+
+	// HTTPS reports whether the response was served over HTTPS
+	HTTPS bool `json:"https"`
+
+	// Redirects is the redirect chain followed when requested
+	Redirects *RedirectChain `json:"redirects,omitempty"`
+
+	// TLS reports the validity of the site's certificate unless the check
+	// was skipped
+	TLS *TLSInfo `json:"tls,omitempty"`
+
+	// StatusCode is the status of the analyzed response, and Warnings
+	// explains why its headers may not be representative
+	StatusCode int      `json:"statusCode,omitempty"`
+	Warnings   []string `json:"warnings,omitempty"`
+
+	// RequestID echoes the client-supplied request ID, if any
+	RequestID string `json:"requestId,omitempty"`
+
+	// AnalyzedAt is when the headers were analyzed, and Cached reports
+	// whether the result was reused from the result cache
+	AnalyzedAt time.Time `json:"analyzedAt"`
+	Cached     bool      `json:"cached"`
+
+	// ScoreBreakdown lists the points each part of the scoring model
+	// contributed to the score
+	ScoreBreakdown *ScoreBreakdown `json:"scoreBreakdown,omitempty"`
+
+	// ConfigurationQualityScore rates the values of the present headers
+	// only, ignoring missing ones, when requested
+	ConfigurationQualityScore *int `json:"configurationQualityScore,omitempty"`
+
+	// Confidence is high, medium or low depending on how likely the response
+	// reflects the real application, and ConfidenceNotes explains why it was
+	// lowered, when requested
+	Confidence      string   `json:"confidence,omitempty"`
+	ConfidenceNotes []string `json:"confidenceNotes,omitempty"`
+
+	// ScaledScore is the score mapped onto 0-Scale, to one decimal place
+	Scale       int      `json:"scale,omitempty"`
+	ScaledScore *float64 `json:"scaledScore,omitempty"`
+
+	// GradeLabel is the configured alternative to the letter grade
+	GradeLabel string `json:"gradeLabel,omitempty"`
+
+	// GradeCapReason explains why the grade is lower than the score alone
+	// would earn
+	GradeCapReason string `json:"gradeCapReason,omitempty"`
+
+	// SiteType is the weight preset the score was computed with
+	SiteType SiteType `json:"siteType,omitempty"`
+
+	// Profile is the scoring profile used when it is not the default one
+	Profile ScoringProfile `json:"profile,omitempty"`
+
+	// RiskLevel summarizes the result in plain language, and RiskFactors
+	// lists the findings that raised it above what the grade implies
+	RiskLevel   string   `json:"riskLevel"`
+	RiskFactors []string `json:"riskFactors,omitempty"`
+
+	// PotentialGains estimates the points gained by fixing every missing or
+	// weak header of a tier, keyed by tier name
+	PotentialGains map[string]int `json:"potentialGains"`
+
+	// NextGrade is the smallest set of fixes reaching the next grade band
+	NextGrade *NextGrade `json:"nextGrade,omitempty"`
+
+	// AllHeaders holds every response header when explicitly requested
+	AllHeaders map[string][]string `json:"allHeaders,omitempty"`
+
+	// ResponseHeaderCount is the number of distinct headers the target
+	// returned
+	ResponseHeaderCount int `json:"responseHeaderCount,omitempty"`
+
+	// UnrecognizedHeaders lists returned headers that look security
+	// relevant but are not checked, in verbose analyses
+	UnrecognizedHeaders []string `json:"unrecognizedHeaders,omitempty"`
+
+	// Preflight is the outcome of the CORS preflight request, if one was sent
+	Preflight *PreflightResult `json:"preflight,omitempty"`
+
+	// Resolvers holds the per-resolver analyses and ResolverDifferences
+	// how they deviate from the analysis through the default resolver
+	Resolvers           []ResolverResult `json:"resolvers,omitempty"`
+	ResolverDifferences []string         `json:"resolverDifferences,omitempty"`
+
+	// ReportEndpoints lists the probed CSP violation reporting endpoints
+	ReportEndpoints []ReportEndpoint `json:"reportEndpoints,omitempty"`
+
+	// Upgrade describes the HTTP to HTTPS redirect when requested
+	Upgrade *UpgradeResult `json:"upgrade,omitempty"`
+
+	// TransportSecurity describes how the host is served over HTTP and
+	// HTTPS when requested
+	TransportSecurity *TransportSecurity `json:"transportSecurity,omitempty"`
+
+	// SchemeDiscrepancies lists the checked headers served differently over
+	// HTTP and HTTPS when requested
+	SchemeDiscrepancies *SchemeDiscrepancies `json:"schemeDiscrepancies,omitempty"`
+
+	// ConfigDiff holds the server config changes when requested
+	ConfigDiff *ConfigDiff `json:"configDiff,omitempty"`
+
+	// Baseline is the difference from the reference server when requested
+	Baseline *Baseline `json:"baseline,omitempty"`
+
+	// Compliance maps the checked headers to framework controls when requested
+	Compliance []ComplianceEntry `json:"compliance,omitempty"`
+
+	// Request describes the request the analyzer sent
+	Request *RequestInfo `json:"request,omitempty"`
+
+	// Assets holds the header posture of sampled static assets
+	Assets []AssetResult `json:"assets,omitempty"`
+
+	// EmptyHeaders lists the checked headers sent with an empty value
+	EmptyHeaders []string `json:"emptyHeaders,omitempty"`
+
+	// Cookies reports the security attributes of every cookie the response sets
+	Cookies []CookieFinding `json:"cookies,omitempty"`
+
+	// LinkHints lists the security-relevant resource hints of the Link
+	// header; they do not affect the score
+	LinkHints []LinkHint `json:"linkHints,omitempty"`
+
+	// Disclosures lists headers that leak server implementation details
+	Disclosures []SecurityHeader `json:"disclosures,omitempty"`
+
+	// Deprecated lists obsolete headers that should be removed or replaced;
+	// they do not affect the score
+	Deprecated []SecurityHeader `json:"deprecated,omitempty"`
+
+	// CORS lists risky patterns in the response's CORS policy
+	CORS []SecurityHeader `json:"cors,omitempty"`
+
+	// Signature is an HMAC of the rest of the result, proving it came
+	// unaltered from this analyzer, when signing was requested
+	Signature string `json:"signature,omitempty"`
+
+	// RemoteAddr is the address the analyzer actually connected to, and
+	// AddressFamily is either "ipv4" or "ipv6"
+	RemoteAddr    string `json:"remoteAddr,omitempty"`
+	AddressFamily string `json:"addressFamily,omitempty"`
 }
 
 // SecurityHeaderTier represents the importance tier of security headers
 type SecurityHeaderTier int
 
+// The zero tier is left unset on entries that are not checked headers,
+// such as disclosures
 const (
-	Critical SecurityHeaderTier = iota // Must have for good security
-	Important                          // Should have for good security  
-	Recommended                        // Nice to have for excellent security
+	Critical    SecurityHeaderTier = iota + 1 // Must have for good security
+	Important                                 // Should have for good security
+	Recommended                               // Nice to have for excellent security
 )
 
+// String returns the lowercase name of the tier
+func (t SecurityHeaderTier) String() string {
+	switch t {
+	case Critical:
+		return "critical"
+	case Important:
+		return "important"
+	case Recommended:
+		return "recommended"
+	default:
+		return ""
+	}
+}
+
+// MarshalText encodes the tier by name
+func (t SecurityHeaderTier) MarshalText() ([]byte, error) {
+	return []byte(t.String()), nil
+}
+
+// UnmarshalText decodes a tier name
+func (t *SecurityHeaderTier) UnmarshalText(text []byte) error {
+	for _, tier := range []SecurityHeaderTier{Critical, Important, Recommended} {
+		if string(text) == tier.String() {
+			*t = tier
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown tier %q", text)
+}
+
+// Severity describes how serious a finding is
+type Severity string
+
+const (
+	SeverityNone   Severity = "none"
+	SeverityLow    Severity = "low"
+	SeverityMedium Severity = "medium"
+	SeverityHigh   Severity = "high"
+)
+
+// validSeverity reports whether s is one of the known severities
+func validSeverity(s Severity) bool {
+	switch s {
+	case SeverityNone, SeverityLow, SeverityMedium, SeverityHigh:
+		return true
+	}
+	return false
+}
+
+// tierSeverity maps a header tier to the severity of that header being missing
+var tierSeverity = map[SecurityHeaderTier]Severity{
+	Critical:    SeverityHigh,
+	Important:   SeverityMedium,
+	Recommended: SeverityLow,
+}
+
+// missingSeverity is the severity of a header being missing or empty. A
+// header weighted 0 is informational and has none.
+func missingSeverity(header SecurityHeader) Severity {
+	if header.Weight == 0 {
+		return SeverityNone
+	}
+	return tierSeverity[header.Tier]
+}
+
 var securityHeaders = []SecurityHeader{
 	// Critical headers (40% of total score)
 	{
 		Name:        "Strict-Transport-Security",
 		Description: "Forces HTTPS connections to protect against man-in-the-middle attacks.",
 		Weight:      20, // Most important for transport security
+		Tier:        Critical,
+		recommended: "max-age=63072000; includeSubDomains; preload",
 	},
 	{
 		Name:        "X-Content-Type-Options",
 		Description: "Prevents MIME-sniffing attacks by enforcing declared content types.",
 		Weight:      15, // Critical for preventing content-type confusion
+		Tier:        Critical,
+		recommended: "nosniff",
 	},
 	{
 		Name:        "X-Frame-Options",
 		Description: "Protects against clickjacking by controlling iframe embedding.",
 		Weight:      15, // Critical for preventing clickjacking
+		Tier:        Critical,
+		recommended: "DENY",
 	},
-	
+
 	// Important headers (35% of total score)
 	{
 		Name:        "Content-Security-Policy",
 		Description: "Helps prevent XSS attacks by defining allowed content sources.",
 		Weight:      20, // Very important but complex to implement correctly
+		Tier:        Important,
+		recommended: "default-src 'self'; object-src 'none'; base-uri 'self'; frame-ancestors 'none'",
 		Aliases:     []string{"Content-Security-Policy-Report-Only"},
 	},
 	{
 		Name:        "Referrer-Policy",
 		Description: "Controls how much referrer information is shared with requests.",
 		Weight:      15, // Important for privacy
+		Tier:        Important,
+		recommended: "strict-origin-when-cross-origin",
 	},
-	
+
 	// Recommended headers (25% of total score)
 	{
 		Name:        "Permissions-Policy",
 		Description: "Controls which browser features and APIs can be used.",
 		Weight:      10, // Modern security feature
+		Tier:        Recommended,
 		Aliases:     []string{"Feature-Policy"},
 	},
 	{
 		Name:        "Cross-Origin-Opener-Policy",
 		Description: "Prevents cross-origin attacks by isolating browsing context.",
 		Weight:      8, // Newer security feature
+		Tier:        Recommended,
+		recommended: "same-origin",
 	},
 	{
 		Name:        "Cross-Origin-Resource-Policy",
 		Description: "Protects resources from being loaded by other origins.",
 		Weight:      7, // Newer security feature
+		Tier:        Recommended,
+		recommended: "same-origin",
+	},
+	{
+		Name:        "Cross-Origin-Embedder-Policy",
+		Description: "Completes cross-origin isolation with COOP by only embedding resources that opt in.",
+		Weight:      0, // Informational: breaks pages embedding third-party resources that do not opt in
+		Tier:        Recommended,
+		recommended: "require-corp",
+	},
+	{
+		Name:        "Set-Login",
+		Description: "Signals the user's login status to the browser for FedCM identity providers.",
+		Weight:      0, // Informational: only relevant to identity providers
+		Tier:        Recommended,
 	},
 }
 
@@ -89,170 +385,717 @@ func isHeaderPresent(resp *http.Response, header SecurityHeader) bool {
 	if resp.Header.Get(header.Name) != "" {
 		return true
 	}
-	
+
 	// Check aliases
 	for _, alias := range header.Aliases {
 		if resp.Header.Get(alias) != "" {
 			return true
 		}
 	}
-	
+
 	return false
 }
 
+// isHeaderEmpty checks if a security header, or one of its aliases, is
+// sent with nothing but an empty value
+func isHeaderEmpty(resp *http.Response, header SecurityHeader) bool {
+	for _, name := range append([]string{header.Name}, header.Aliases...) {
+		if _, sent := resp.Header[http.CanonicalHeaderKey(name)]; sent {
+			return true
+		}
+	}
+	return false
+}
+
+// valueChecks inspect the value of a present header and may lower the
+// weight it is awarded or attach issues to its summary entry
+var valueChecks = map[string]func(item *SecurityHeader, header http.Header){
+	"Strict-Transport-Security":    checkHSTS,
+	"X-Frame-Options":              checkXFrameOptions,
+	"Content-Security-Policy":      checkCSP,
+	"Permissions-Policy":           checkPermissionsPolicy,
+	"Set-Login":                    checkSetLogin,
+	"Referrer-Policy":              checkReferrerPolicy,
+	"Cross-Origin-Embedder-Policy": checkCOEP,
+	"Cross-Origin-Opener-Policy":   checkCOOP,
+}
+
+// headerValue returns the value of a security header, falling back to its
+// aliases, and the name it was found under
+func headerValue(resp *http.Response, header SecurityHeader) (string, string) {
+	for _, name := range append([]string{header.Name}, header.Aliases...) {
+		if value := resp.Header.Get(name); value != "" {
+			return value, name
+		}
+	}
+	return "", ""
+}
+
+// Options customizes a single analysis
+type Options struct {
+	// Filter restricts the checked headers to those whose names match it.
+	// Scoring is then relative to the weights of the matching headers only.
+	Filter *regexp.Regexp
+
+	// IncludeAllHeaders attaches every response header to the result,
+	// with sensitive values redacted
+	IncludeAllHeaders bool
+
+	// Verbose attaches every response header like IncludeAllHeaders and
+	// lists the unchecked headers that look security relevant
+	Verbose bool
+
+	// Preflight, when set, also sends a CORS preflight request and reports
+	// the response separately
+	Preflight *PreflightOptions
+
+	// Resolvers, when set, repeats the analysis once per DNS resolver
+	// (host:port) to surface CDN or geo-specific differences
+	Resolvers []string
+
+	// CheckReportEndpoints probes the CSP report-uri/report-to endpoints
+	// for reachability
+	CheckReportEndpoints bool
+
+	// IncludeCompliance maps each checked header onto the framework
+	// controls it provides evidence for
+	IncludeCompliance bool
+
+	// IncludeAssets samples the scripts and stylesheets the page references
+	// and reports their header posture separately
+	IncludeAssets bool
+
+	// IncludeRequestInfo reports the request the analyzer sent
+	IncludeRequestInfo bool
+
+	// IncludeQualityScore adds ConfigurationQualityScore, which grades only
+	// the values of the headers that are present
+	IncludeQualityScore bool
+
+	// FollowRedirects follows up to maxRedirects redirects and scores the
+	// final response, recording the chain in Redirects
+	FollowRedirects bool
+
+	// Timeout bounds each request of the analysis, overriding the
+	// configured client timeout when positive
+	Timeout time.Duration
+
+	// IncludeConfidence adds Confidence, estimating whether the response
+	// represents the real application rather than a WAF, error or redirect
+	IncludeConfidence bool
+
+	// Authenticated tells whether the endpoint requires a login: cookie
+	// findings are escalated when true and downgraded when false. Nil keeps
+	// them at a moderate baseline.
+	Authenticated *bool
+
+	// CheckUpgrade also requests the site over plain HTTP and reports how
+	// the redirect to HTTPS behaves
+	CheckUpgrade bool
+
+	// CheckTransport requests the host over both HTTP and HTTPS and reports
+	// whether each is served, whether HTTP redirects to HTTPS and whether
+	// HSTS prevents downgrades
+	CheckTransport bool
+
+	// CheckSchemes requests the site over both HTTP and HTTPS and reports
+	// the checked headers that differ between them
+	CheckSchemes bool
+
+	// Profile scores the result with another scoring profile, such as
+	// ProfileMozilla, instead of the default model
+	Profile ScoringProfile
+
+	// ConfigServer, when set to nginx, caddy or apache, adds the config
+	// changes that fix the missing and weak headers for that server
+	ConfigServer string
+
+	// Sort orders the summary by weight (descending), name or tier; the
+	// zero value keeps the definition order
+	Sort string
+
+	// Scale, when positive, adds ScaledScore: the score mapped linearly
+	// onto 0-Scale
+	Scale int
+
+	// RequireValidCertificate fails the analysis with a CertificateError
+	// when the certificate is not trusted, instead of analyzing anyway
+	RequireValidCertificate bool
+
+	// RequireSuccess fails the analysis with a StatusError when the
+	// analyzed response is not a 2xx, instead of scoring it with a warning
+	RequireSuccess bool
+
+	// InsecureSkipVerify skips the certificate check reported under TLS,
+	// for intentionally broken test endpoints
+	InsecureSkipVerify bool
+
+	// SiteType selects a weight preset; the zero value keeps the balanced
+	// default weights
+	SiteType SiteType
+
+	// Method is the request method to analyze the response of; the zero
+	// value sends GET
+	Method string
+
+	// Weights overrides the weight of the named headers for this analysis,
+	// on top of the site type preset
+	Weights map[string]int
+
+	// RequestHeaders are sent with the request, e.g. an Origin to see the
+	// CORS response or an Accept to reach an API representation
+	RequestHeaders map[string]string
+
+	// UserAgent replaces the configured User-Agent for this analysis,
+	// including one set in RequestHeaders
+	UserAgent string
+}
+
+// ErrNoHeadersMatched is returned when a filter excludes every checked header
+var ErrNoHeadersMatched = errors.New("filter does not match any checked header")
+
+// selectHeaders returns the given security headers that pass the filter
+func selectHeaders(headers []SecurityHeader, filter *regexp.Regexp) []SecurityHeader {
+	if filter == nil {
+		return headers
+	}
+
+	selected := make([]SecurityHeader, 0, len(headers))
+	for _, header := range headers {
+		if filter.MatchString(header.Name) {
+			selected = append(selected, header)
+		}
+	}
+	return selected
+}
+
 func AnalyzeURL(url string) (*AnalysisResult, error) {
-	if !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://") {
-		url = "https://" + url
+	return AnalyzeURLContext(context.Background(), url)
+}
+
+// AnalyzeURLContext fetches url and analyzes its response headers. Every
+// request the analysis sends is abandoned once ctx is done.
+func AnalyzeURLContext(ctx context.Context, url string) (*AnalysisResult, error) {
+	return analyze(ctx, url, Options{})
+}
+
+// AnalyzeURLWithOptions fetches url and analyzes its response headers
+func AnalyzeURLWithOptions(url string, opts Options) (*AnalysisResult, error) {
+	return AnalyzeURLWithOptionsContext(context.Background(), url, opts)
+}
+
+// AnalyzeURLWithOptionsContext is AnalyzeURLWithOptions abandoned once ctx
+// is done
+func AnalyzeURLWithOptionsContext(ctx context.Context, url string, opts Options) (*AnalysisResult, error) {
+	return analyze(ctx, url, opts)
+}
+
+// dialFunc opens the connection to a target
+type dialFunc func(ctx context.Context, network, addr string) (net.Conn, error)
+
+// newDialer returns the dialer used for outbound connections. Dialing over
+// "tcp" tries both IPv4 and IPv6 addresses, racing the families (Happy
+// Eyeballs) on dual-stack hosts. Connections to internal addresses are
+// refused with ErrBlockedAddress.
+func newDialer() *net.Dialer {
+	return &net.Dialer{
+		Timeout:       10 * time.Second,
+		FallbackDelay: 300 * time.Millisecond,
+		Control:       guardAddress,
 	}
+}
 
-	client := &http.Client{
-		Timeout: 10 * time.Second,
-		Transport: &http.Transport{
-			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
-		},
+// Transports shared by every analysis so kept-alive connections are pooled.
+// Certificates are not verified when fetching, so that sites with broken
+// certificates can still be analyzed, except on verifyingTransport, used
+// when a valid certificate is required.
+var (
+	sharedTransport    = newTransport(nil, false)
+	verifyingTransport = newTransport(nil, true)
+)
+
+// newTransport builds a transport for fetching targets. A nil dial uses
+// the default dialer.
+func newTransport(dial dialFunc, verify bool) *http.Transport {
+	if dial == nil {
+		dial = newDialer().DialContext
+	}
+
+	return &http.Transport{
+		DialContext:         dial,
+		TLSClientConfig:     &tls.Config{InsecureSkipVerify: !verify},
+		DisableCompression:  true,
+		MaxIdleConns:        100,
+		MaxIdleConnsPerHost: 4,
+		IdleConnTimeout:     90 * time.Second,
+	}
+}
+
+// newClient builds the HTTP client used to fetch targets over transport.
+// Redirects are not followed so the headers of the exact URL requested are
+// analyzed. Every round trip counts against the global fetch limit and is
+// bounded by timeout, or by the configured client timeout when it is 0.
+// Clients are cheap; the connections live in the transport.
+func newClient(transport http.RoundTripper, timeout time.Duration) *http.Client {
+	if timeout <= 0 {
+		timeout = config.ClientTimeout
+	}
+
+	return &http.Client{
+		Transport: &limitedTransport{base: transport, timeout: timeout},
 		CheckRedirect: func(req *http.Request, via []*http.Request) error {
 			return http.ErrUseLastResponse
 		},
 	}
+}
+
+// analyze runs an analysis with the default analyzer that is abandoned once
+// ctx is done
+func analyze(ctx context.Context, url string, opts Options) (*AnalysisResult, error) {
+	return defaultAnalyzer.AnalyzeWithOptions(ctx, url, opts)
+}
+
+// run fetches url and analyzes its response headers
+func (a *Analyzer) run(ctx context.Context, url string, opts Options) (*AnalysisResult, error) {
+	if opts.Profile == ProfileDefault {
+		opts.Profile = a.profile
+	}
+	if !ValidSiteType(opts.SiteType) {
+		return nil, ErrUnknownSiteType
+	}
+	if !ValidSort(opts.Sort) {
+		return nil, ErrUnknownSort
+	}
+	if opts.ConfigServer != "" && !ValidConfigServer(opts.ConfigServer) {
+		return nil, ErrUnknownServer
+	}
+	if !ValidMethod(opts.Method) {
+		return nil, ErrUnknownMethod
+	}
+	if !ValidProfile(opts.Profile) {
+		return nil, ErrUnknownProfile
+	}
+	if err := validateWeights(opts.Weights); err != nil {
+		return nil, err
+	}
+
+	headers := selectHeaders(a.definitions(), opts.Filter)
+	if len(headers) == 0 {
+		return nil, ErrNoHeadersMatched
+	}
+	headers = applyWeights(applySiteType(headers, opts.SiteType), opts.Weights)
+
+	url, err := normalizeURL(url)
+	if err != nil {
+		return nil, err
+	}
+
+	transport := a.transport
+	if opts.RequireValidCertificate {
+		transport = a.verifying
+	}
+	client := newClient(transport, opts.Timeout)
+
+	var remoteAddr net.Addr
+	written := make(writtenHeaders)
+	trace := &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			remoteAddr = info.Conn.RemoteAddr()
+		},
+		WroteHeaderField: written.add,
+	}
 
-	resp, err := client.Get(url)
+	req, err := newAnalysisRequest(httptrace.WithClientTrace(ctx, trace), url, opts)
 	if err != nil {
 		return nil, err
 	}
+
+	fetcher := client
+	var redirects *RedirectChain
+	if opts.FollowRedirects {
+		redirects = &RedirectChain{Hops: make([]RedirectHop, 0)}
+		fetcher = followRedirects(client, redirects, func() { clear(written) })
+	}
+
+	resp, err := fetcher.Do(req)
+	if err != nil {
+		return nil, fetchError(url, err)
+	}
 	defer resp.Body.Close()
 
+	body := readBody(resp)
+	finalURL := url
+	if redirects != nil {
+		finalURL = resp.Request.URL.String()
+		finishRedirectChain(redirects, url, resp.Request.URL)
+	}
+	if opts.RequireSuccess && !successStatus(resp.StatusCode) {
+		return nil, &StatusError{URL: finalURL, StatusCode: resp.StatusCode}
+	}
+	result := scoreResponse(finalURL, headers, resp, opts.Authenticated)
+	result.URL = url
+	result.Redirects = redirects
+	result.SiteType = opts.SiteType
+	if !opts.InsecureSkipVerify {
+		result.TLS = inspectTLS(resp)
+	}
+
+	var upgrade *UpgradeResult
+	if opts.CheckUpgrade || normalizeProfile(opts.Profile) == ProfileMozilla {
+		upgrade = probeUpgrade(ctx, client, url)
+	}
+	if opts.CheckUpgrade {
+		result.Upgrade = upgrade
+	}
+	applyProfile(result, scoringInput{result: result, header: resp.Header, upgrade: upgrade}, opts.Profile)
+
+	if remoteAddr != nil {
+		result.RemoteAddr = remoteAddr.String()
+		result.AddressFamily = addressFamily(remoteAddr)
+	}
+
+	if opts.Scale > 0 {
+		scaled := scaleScore(result.Score, opts.Scale)
+		result.Scale, result.ScaledScore = opts.Scale, &scaled
+	}
+
+	if opts.IncludeQualityScore {
+		if quality, ok := configurationQuality(result.Summary); ok {
+			result.ConfigurationQualityScore = &quality
+		}
+	}
+
+	if opts.IncludeConfidence {
+		result.Confidence, result.ConfidenceNotes = assessConfidence(url, resp)
+	}
+
+	if opts.IncludeRequestInfo {
+		result.Request = requestInfo(resp, written)
+	}
+
+	if opts.IncludeAllHeaders || opts.Verbose {
+		result.AllHeaders = collectHeaders(resp.Header)
+	}
+	if opts.Verbose {
+		result.UnrecognizedHeaders = unrecognizedHeaders(resp.Header, a.definitions())
+	}
+
+	if opts.Preflight != nil {
+		result.Preflight = runPreflight(ctx, client, url, *opts.Preflight)
+	}
+
+	if opts.CheckReportEndpoints {
+		result.ReportEndpoints = probeReportEndpoints(ctx, client, reportEndpoints(finalURL, resp.Header))
+		for _, endpoint := range result.ReportEndpoints {
+			if !endpoint.Reachable {
+				markReportEndpointUnreachable(result, endpoint)
+			}
+		}
+	}
+
+	if opts.CheckTransport {
+		result.TransportSecurity = probeTransport(ctx, client, url)
+	}
+
+	if opts.CheckSchemes {
+		result.SchemeDiscrepancies = compareSchemes(ctx, client, url, headers)
+	}
+
+	if opts.IncludeAssets {
+		result.Assets = sampleAssets(ctx, client, resp, body)
+	}
+
+	if opts.ConfigServer != "" {
+		result.ConfigDiff = buildConfigDiff(opts.ConfigServer, result.Summary)
+	}
+
+	if opts.IncludeCompliance {
+		result.Compliance = complianceFor(result.Summary)
+	}
+
+	if len(opts.Resolvers) > 0 {
+		result.Resolvers = analyzeThroughResolvers(ctx, url, headers, opts)
+		result.ResolverDifferences = resolverDifferences(result, result.Resolvers)
+	}
+
+	sortSummary(result.Summary, opts.Sort)
+
+	return result, nil
+}
+
+// newAnalysisRequest builds the request an analysis sends to url, with the
+// method, request headers and User-Agent of opts
+func newAnalysisRequest(ctx context.Context, url string, opts Options) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, requestMethod(opts.Method), url, nil)
+	if err != nil {
+		return nil, err
+	}
+	setRequestHeaders(req, opts.RequestHeaders)
+	if opts.UserAgent != "" {
+		req.Header.Set("User-Agent", opts.UserAgent)
+	}
+	requestCompression(req)
+	return req, nil
+}
+
+// scoreResponse checks the given security headers against a response and
+// scores the result
+func scoreResponse(url string, headers []SecurityHeader, resp *http.Response, authenticated *bool) *AnalysisResult {
+	return scoreHeaders(url, isHTTPS(url), headers, resp, authenticated)
+}
+
+// AnalyzeHeaders scores already captured response headers without fetching
+// anything, for responses the analyzer cannot reach itself
+func AnalyzeHeaders(header http.Header, isHTTPS bool) *AnalysisResult {
+	return scoreHeaders("", isHTTPS, securityHeaders, &http.Response{Header: header}, nil)
+}
+
+// scoreHeaders scores the headers of resp, which was served over HTTPS when
+// https is set. authenticated sets the severity of cookie findings.
+func scoreHeaders(url string, https bool, headers []SecurityHeader, resp *http.Response, authenticated *bool) *AnalysisResult {
 	result := &AnalysisResult{
-		Headers: make(map[string]bool),
-		Summary: make([]SecurityHeader, 0),
-		URL:     url,
+		Headers:    make(map[string]bool),
+		Summary:    make([]SecurityHeader, 0),
+		URL:        url,
+		HTTPS:      https,
+		StatusCode: resp.StatusCode,
+		AnalyzedAt: time.Now().UTC(),
+
+		ResponseHeaderCount: len(resp.Header),
+	}
+	if resp.StatusCode != 0 && !successStatus(resp.StatusCode) {
+		result.Warnings = append(result.Warnings, statusWarning(resp.StatusCode))
 	}
 
-	totalWeight := 0
-	achievedWeight := 0
+	trailers := trailerResponse(resp)
 
-	for _, header := range securityHeaders {
+	for _, header := range headers {
+		source, origin := resp, ""
 		present := isHeaderPresent(resp, header)
+		if !present && trailers != nil && isHeaderPresent(trailers, header) {
+			source, origin = trailers, SourceTrailer
+			present = true
+		}
 		result.Headers[header.Name] = present
 
+		value, foundAs := headerValue(source, header)
 		summaryItem := SecurityHeader{
 			Name:        header.Name,
 			Present:     present,
 			Description: header.Description,
 			Weight:      header.Weight,
+			Tier:        header.Tier,
 			Aliases:     header.Aliases,
+			Value:       value,
+			Source:      origin,
+		}
+		if foundAs != header.Name {
+			summaryItem.MatchedName = foundAs
+		}
+		if present {
+			summaryItem.Awarded = header.Weight
+			if check, ok := valueChecks[header.Name]; ok {
+				check(&summaryItem, source.Header)
+			}
+		} else if isHeaderEmpty(resp, header) {
+			// sent without a value: present, but no protection and no credit
+			summaryItem.Present = true
+			summaryItem.Empty = true
+			summaryItem.Severity = missingSeverity(header)
+			summaryItem.Issues = append(summaryItem.Issues, "header is sent with an empty value and provides no protection")
+			summaryItem.Remediation = remediation(header.Name)
+			result.Headers[header.Name] = true
+			result.EmptyHeaders = append(result.EmptyHeaders, header.Name)
+		} else {
+			summaryItem.Severity = missingSeverity(header)
+			summaryItem.Remediation = remediation(header.Name)
 		}
 		result.Summary = append(result.Summary, summaryItem)
+	}
+
+	result.Disclosures = detectDisclosures(resp.Header)
+	result.Deprecated = detectDeprecated(resp.Header)
+	result.Cookies = checkCookies(resp, authenticated)
+	result.CORS = detectCORS(resp.Header, requestOrigin(resp))
+	result.LinkHints = linkHints(url, resp.Header)
+	redactSummary(result.Summary)
+	redactSummary(result.Disclosures)
+	redactSummary(result.Deprecated)
+
+	result.ScoreBreakdown = defaultScore(scoringInput{result: result, header: resp.Header})
+	result.Score = result.ScoreBreakdown.Total
+	result.PotentialGains = potentialGains(result.Summary, https)
+	result.Grade, result.GradeCapReason = capGrade(calculateGrade(result.Score), result.Summary, https)
+	result.GradeLabel = gradeLabel(result.Score)
+	result.NextGrade = ComputeNextGrade(result)
+	result.RiskLevel, result.RiskFactors = classifyRisk(result, resp.Header)
+
+	return result
+}
+
+// addressFamily reports whether addr is an IPv4 or IPv6 address
+func addressFamily(addr net.Addr) string {
+	tcpAddr, ok := addr.(*net.TCPAddr)
+	if !ok {
+		return ""
+	}
+	if tcpAddr.IP.To4() != nil {
+		return "ipv4"
+	}
+	return "ipv6"
+}
+
+// servedOverHTTPS reports whether the result was served over HTTPS. The
+// URL is consulted too for results recorded before HTTPS was reported.
+func (r *AnalysisResult) servedOverHTTPS() bool {
+	return r.HTTPS || isHTTPS(r.URL)
+}
+
+// deduction is the number of points disclosures, insecure cookies and
+// CORS findings take off the header score
+func (r *AnalysisResult) deduction() int {
+	return -sumScoreItems(r.deductionItems())
+}
+
+// computeScore calculates the 0-100 score for a summary of checked headers
+func computeScore(summary []SecurityHeader, https bool) int {
+	return sumScoreItems(scoreItems(summary, https))
+}
+
+// scoreItems breaks the 0-100 score for a summary of checked headers down
+// into the points each part of the scoring model contributes
+func scoreItems(summary []SecurityHeader, https bool) []ScoreItem {
+	totalWeight := 0
+	lostWeight := 0.0
 
+	for _, header := range summary {
 		totalWeight += header.Weight
-		if present {
-			achievedWeight += header.Weight
+		if !header.Present {
+			penalty := float64(header.Weight)
+			if header.tier() == Critical {
+				penalty *= config.CriticalPenaltyMultiplier
+			}
+			lostWeight += penalty
+		} else {
+			lostWeight += float64(header.Weight - header.Awarded)
 		}
 	}
 
 	// Calculate base score from security headers (70% of total)
 	headerScore := 0
 	if totalWeight > 0 {
-		headerScore = (achievedWeight * 70) / totalWeight
+		headerScore = int((float64(totalWeight) - lostWeight) * 70 / float64(totalWeight))
+		if headerScore < 0 {
+			headerScore = 0
+		}
 	}
 
 	// HTTPS is fundamental (30 points base)
 	httpsScore := 0
-	if strings.HasPrefix(url, "https://") {
+	if https {
 		httpsScore = 30
 	}
 
-	// Combine base scores
-	result.Score = headerScore + httpsScore
-
 	// Apply tiered bonuses for security coverage
-	criticalCount := countCriticalHeaders(result.Summary)
-	importantCount := countImportantHeaders(result.Summary) 
-	
+	criticalCount := countCriticalHeaders(summary)
+	importantCount := countImportantHeaders(summary)
+
 	// Bonus for having critical headers (up to 10 points)
-	if criticalCount > 0 {
-		criticalBonus := (criticalCount * 10) / 3 // Up to 10 points for all 3 critical headers
-		if criticalBonus > 10 {
-			criticalBonus = 10
-		}
-		result.Score += criticalBonus
+	criticalBonus := (criticalCount * 10) / 3 // Up to 10 points for all 3 critical headers
+	if criticalBonus > 10 {
+		criticalBonus = 10
 	}
-	
+
 	// Bonus for having important headers (up to 5 points)
-	if importantCount > 0 {
-		importantBonus := (importantCount * 5) / 2 // Up to 5 points for both important headers
-		if importantBonus > 5 {
-			importantBonus = 5
-		}
-		result.Score += importantBonus
+	importantBonus := (importantCount * 5) / 2 // Up to 5 points for both important headers
+	if importantBonus > 5 {
+		importantBonus = 5
+	}
+
+	items := []ScoreItem{
+		{Label: "Security headers", Points: headerScore},
+		{Label: "HTTPS", Points: httpsScore},
+		{Label: "Critical header bonus", Points: criticalBonus},
+		{Label: "Important header bonus", Points: importantBonus},
 	}
 
 	// Cap at 100
-	if result.Score > 100 {
-		result.Score = 100
+	if score := sumScoreItems(items); score > 100 {
+		items = append(items, ScoreItem{Label: "Capped at 100", Points: 100 - score})
 	}
 
-	result.Grade = calculateGrade(result.Score)
+	return items
+}
 
-	return result, nil
+// scaleScore maps a 0-100 score linearly onto 0-scale, to one decimal place
+func scaleScore(score, scale int) float64 {
+	return math.Round(float64(score)*float64(scale)/10) / 10
 }
 
-// hasAnyCriticalHeader checks if the site has at least one critical security header
-func hasAnyCriticalHeader(summary []SecurityHeader) bool {
-	criticalHeaders := []string{"Strict-Transport-Security", "X-Content-Type-Options", "X-Frame-Options"}
-	
-	for _, header := range summary {
-		for _, critical := range criticalHeaders {
-			if header.Name == critical && header.Present {
-				return true
+// potentialGains computes, per tier, how many points the score would rise
+// if every header of that tier earned its full weight
+func potentialGains(summary []SecurityHeader, https bool) map[string]int {
+	current := computeScore(summary, https)
+	gains := make(map[string]int)
+
+	for _, tier := range []SecurityHeaderTier{Critical, Important, Recommended} {
+		fixed := make([]SecurityHeader, len(summary))
+		copy(fixed, summary)
+		for i := range fixed {
+			if fixed[i].tier() == tier {
+				fixed[i].Present = true
+				fixed[i].Awarded = fixed[i].Weight
 			}
 		}
+		gains[tier.String()] = computeScore(fixed, https) - current
 	}
-	return false
+
+	return gains
 }
 
-// countCriticalHeaders counts how many critical headers are present
-func countCriticalHeaders(summary []SecurityHeader) int {
-	criticalHeaders := []string{"Strict-Transport-Security", "X-Content-Type-Options", "X-Frame-Options"}
-	count := 0
-	
-	for _, header := range summary {
-		for _, critical := range criticalHeaders {
-			if header.Name == critical && header.Present {
-				count++
-				break
-			}
+// FailedCriticalHeaders returns the names of critical headers the result is
+// missing or that did not earn their full weight
+func (r *AnalysisResult) FailedCriticalHeaders() []string {
+	var failed []string
+	for _, header := range r.Summary {
+		if header.tier() == Critical && (!header.Present || header.Awarded < header.Weight) {
+			failed = append(failed, header.Name)
 		}
 	}
-	return count
+	return failed
+}
+
+// hasAnyCriticalHeader checks if the site has at least one critical security header
+func hasAnyCriticalHeader(summary []SecurityHeader) bool {
+	return countCriticalHeaders(summary) > 0
 }
 
-// countImportantHeaders counts how many important headers are present
+// countCriticalHeaders counts how many critical headers are present and earn credit
+func countCriticalHeaders(summary []SecurityHeader) int {
+	return countTierHeaders(summary, Critical)
+}
+
+// countImportantHeaders counts how many important headers are present and earn credit
 func countImportantHeaders(summary []SecurityHeader) int {
-	importantHeaders := []string{"Content-Security-Policy", "Referrer-Policy"}
+	return countTierHeaders(summary, Important)
+}
+
+// countTierHeaders counts how many headers of a tier are present and earn credit
+func countTierHeaders(summary []SecurityHeader, tier SecurityHeaderTier) int {
 	count := 0
-	
 	for _, header := range summary {
-		for _, important := range importantHeaders {
-			if header.Name == important && header.Present {
-				count++
-				break
-			}
+		if header.tier() == tier && header.Present && header.Awarded > 0 {
+			count++
 		}
 	}
 	return count
 }
 
 func calculateGrade(score int) string {
-	switch {
-	case score >= 80:
-		return "A"
-	case score >= 65:
-		return "B"
-	case score >= 45:
-		return "C"
-	case score >= 25:
-		return "D"
-	default:
-		return "F"
+	for _, floor := range gradeFloors() {
+		if score >= floor.score {
+			return floor.grade
+		}
 	}
+	return "F"
 }
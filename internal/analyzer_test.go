@@ -0,0 +1,66 @@
+package internal
+
+import (
+	"net/http"
+	"testing"
+)
+
+// recommendedHeaders is a response sending every checked header at its
+// recommended value
+func recommendedHeaders() http.Header {
+	header := make(http.Header)
+	for _, definition := range securityHeaders {
+		if value, ok := recommendedValue(definition.Name, ""); ok {
+			header.Set(definition.Name, value)
+		}
+	}
+	return header
+}
+
+func TestAnalyzeHeadersGrades(t *testing.T) {
+	tests := []struct {
+		name   string
+		header http.Header
+		https  bool
+		grade  string
+		risk   string
+		capped bool
+	}{
+		{"recommended headers over HTTPS", recommendedHeaders(), true, "A", RiskLow, false},
+		{"recommended headers over HTTP", recommendedHeaders(), false, httpsCapGrade, RiskHigh, true},
+		{"no headers over HTTPS", http.Header{}, true, "D", RiskHigh, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := AnalyzeHeaders(tt.header, tt.https)
+			if result.Grade != tt.grade {
+				t.Errorf("grade %s (score %d), want %s", result.Grade, result.Score, tt.grade)
+			}
+			if result.RiskLevel != tt.risk {
+				t.Errorf("risk level %s, want %s", result.RiskLevel, tt.risk)
+			}
+			if capped := result.GradeCapReason != ""; capped != tt.capped {
+				t.Errorf("grade cap %q, want capped %v", result.GradeCapReason, tt.capped)
+			}
+		})
+	}
+}
+
+func TestApplyProfileRecomputesRisk(t *testing.T) {
+	header := recommendedHeaders()
+	result := AnalyzeHeaders(header, true)
+	applyProfile(result, scoringInput{result: result, header: header}, ProfileMozilla)
+
+	if !ValidGrade(result.Grade) {
+		t.Fatalf("mozilla grade %q is not a valid grade", result.Grade)
+	}
+	if want := gradeRisk[result.Grade[:1]]; result.RiskLevel != want {
+		t.Errorf("risk level %s for grade %s, want %s", result.RiskLevel, result.Grade, want)
+	}
+	for tier, gain := range result.PotentialGains {
+		if gain < 0 {
+			t.Errorf("potential gain for %s is %d, want it not negative", tier, gain)
+		}
+	}
+}
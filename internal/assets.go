@@ -0,0 +1,124 @@
+package internal
+
+import (
+	"context"
+	"net/http"
+	neturl "net/url"
+	"regexp"
+	"strings"
+)
+
+// MaxAssets caps how many static assets are sampled per page
+const MaxAssets = 5
+
+var (
+	scriptTag = regexp.MustCompile(`(?is)<script\b[^>]*?\bsrc\s*=\s*["']?([^"'\s>]+)`)
+	linkTag   = regexp.MustCompile(`(?is)<link\b[^>]*>`)
+	relAttr   = regexp.MustCompile(`(?is)\brel\s*=\s*["']?([^"'>]+)`)
+	hrefAttr  = regexp.MustCompile(`(?is)\bhref\s*=\s*["']?([^"'\s>]+)`)
+)
+
+// AssetResult is the header posture of a static asset referenced by a page
+type AssetResult struct {
+	URL         string          `json:"url"`
+	Type        string          `json:"type"`
+	CrossOrigin bool            `json:"crossOrigin"`
+	StatusCode  int             `json:"statusCode,omitempty"`
+	ContentType string          `json:"contentType,omitempty"`
+	Headers     map[string]bool `json:"headers,omitempty"`
+	Missing     []string        `json:"missing,omitempty"`
+	Error       string          `json:"error,omitempty"`
+}
+
+// pageAsset is a script or stylesheet referenced by a page
+type pageAsset struct {
+	url       string
+	assetType string
+}
+
+// extractAssets finds the scripts and stylesheets a page references,
+// resolved against the page URL and without duplicates
+func extractAssets(body string, base *neturl.URL) []pageAsset {
+	seen := make(map[string]bool)
+	assets := make([]pageAsset, 0)
+
+	add := func(ref, assetType string) {
+		u, err := base.Parse(ref)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			return
+		}
+		u.Fragment = ""
+		if !seen[u.String()] {
+			seen[u.String()] = true
+			assets = append(assets, pageAsset{url: u.String(), assetType: assetType})
+		}
+	}
+
+	for _, match := range scriptTag.FindAllStringSubmatch(body, -1) {
+		add(match[1], "script")
+	}
+	for _, tag := range linkTag.FindAllString(body, -1) {
+		rel := relAttr.FindStringSubmatch(tag)
+		href := hrefAttr.FindStringSubmatch(tag)
+		if rel != nil && href != nil && strings.Contains(strings.ToLower(rel[1]), "stylesheet") {
+			add(href[1], "stylesheet")
+		}
+	}
+
+	return assets
+}
+
+// sampleAssets picks up to MaxAssets of the scripts and stylesheets the
+// page body references and checks their response headers
+func sampleAssets(ctx context.Context, client *http.Client, resp *http.Response, body []byte) []AssetResult {
+	assets := extractAssets(string(body), resp.Request.URL)
+	if len(assets) > MaxAssets {
+		assets = assets[:MaxAssets]
+	}
+
+	results := make([]AssetResult, 0, len(assets))
+	for _, asset := range assets {
+		results = append(results, checkAsset(ctx, client, asset, resp.Request.URL))
+	}
+	return results
+}
+
+// checkAsset fetches a single asset and reports the asset-relevant headers
+// it is missing
+func checkAsset(ctx context.Context, client *http.Client, asset pageAsset, page *neturl.URL) AssetResult {
+	result := AssetResult{URL: asset.url, Type: asset.assetType}
+	if u, err := neturl.Parse(asset.url); err == nil {
+		result.CrossOrigin = u.Host != page.Host || u.Scheme != page.Scheme
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, asset.url, nil)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	closeBody(resp)
+
+	result.StatusCode = resp.StatusCode
+	result.ContentType = resp.Header.Get("Content-Type")
+	result.Headers = map[string]bool{
+		"X-Content-Type-Options":       strings.EqualFold(strings.TrimSpace(resp.Header.Get("X-Content-Type-Options")), "nosniff"),
+		"Cross-Origin-Resource-Policy": resp.Header.Get("Cross-Origin-Resource-Policy") != "",
+	}
+	if isHTTPS(asset.url) {
+		result.Headers["Strict-Transport-Security"] = resp.Header.Get("Strict-Transport-Security") != ""
+	}
+
+	for _, name := range []string{"X-Content-Type-Options", "Cross-Origin-Resource-Policy", "Strict-Transport-Security"} {
+		if present, checked := result.Headers[name]; checked && !present {
+			result.Missing = append(result.Missing, name)
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,127 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+const (
+	DefaultBatchConcurrency = 5
+	MaxBatchConcurrency     = 20
+)
+
+// BatchItem is the outcome of analyzing a single URL as part of a batch
+type BatchItem struct {
+	URL       string          `json:"url"`
+	RequestID string          `json:"requestId,omitempty"`
+	Result    *AnalysisResult `json:"result,omitempty"`
+	Error     string          `json:"error,omitempty"`
+}
+
+// BatchReport holds the completed items of a batch and how many URLs were
+// abandoned because the batch deadline passed
+type BatchReport struct {
+	Items   []BatchItem `json:"items"`
+	Skipped int         `json:"skipped"`
+	Note    string      `json:"note,omitempty"`
+}
+
+// SetRequestID tags every item, and its result, with the client-supplied
+// request ID of the batch
+func (r *BatchReport) SetRequestID(id string) {
+	for i := range r.Items {
+		r.Items[i].RequestID = id
+		if r.Items[i].Result != nil {
+			r.Items[i].Result.RequestID = id
+		}
+	}
+}
+
+// AnalyzeBatch analyzes the given URLs using a bounded worker pool.
+// Once ctx is done, URLs that have not completed are skipped and only the
+// completed items are returned, in the same order as the input URLs.
+func AnalyzeBatch(ctx context.Context, urls []string, concurrency int) *BatchReport {
+	return AnalyzeBatchFunc(ctx, urls, concurrency, nil)
+}
+
+// AnalyzeBatchFunc is AnalyzeBatch calling onItem with each item as soon as
+// it completes, in completion order. Calls to onItem are never concurrent;
+// a nil onItem is ignored.
+func AnalyzeBatchFunc(ctx context.Context, urls []string, concurrency int, onItem func(BatchItem)) *BatchReport {
+	return analyzeBatch(ctx, urls, concurrency, nil, onItem)
+}
+
+// analyzeBatch is AnalyzeBatchFunc starting each analysis only once limiter
+// allows it; a nil limiter does not wait. URLs still waiting when ctx is
+// done are skipped.
+func analyzeBatch(ctx context.Context, urls []string, concurrency int, limiter *RateLimiter, onItem func(BatchItem)) *BatchReport {
+	if concurrency <= 0 {
+		concurrency = DefaultBatchConcurrency
+	}
+	if concurrency > MaxBatchConcurrency {
+		concurrency = MaxBatchConcurrency
+	}
+
+	items := make([]BatchItem, len(urls))
+	completed := make([]bool, len(urls))
+	jobs := make(chan int)
+
+	var emitMu sync.Mutex
+	var wg sync.WaitGroup
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				if limiter != nil && limiter.Wait(ctx) != nil {
+					continue
+				}
+				result, err := analyze(ctx, urls[i], Options{})
+				if err != nil && ctx.Err() != nil {
+					// Abandoned by the batch deadline rather than a real failure
+					continue
+				}
+
+				items[i].URL = urls[i]
+				if err != nil {
+					items[i].Error = err.Error()
+				} else {
+					items[i].Result = result
+				}
+				completed[i] = true
+
+				if onItem != nil {
+					emitMu.Lock()
+					onItem(items[i])
+					emitMu.Unlock()
+				}
+			}
+		}()
+	}
+
+dispatch:
+	for i := range urls {
+		select {
+		case jobs <- i:
+		case <-ctx.Done():
+			break dispatch
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	report := &BatchReport{Items: make([]BatchItem, 0, len(urls))}
+	for i, done := range completed {
+		if done {
+			report.Items = append(report.Items, items[i])
+		} else {
+			report.Skipped++
+		}
+	}
+	if report.Skipped > 0 {
+		report.Note = fmt.Sprintf("batch deadline exceeded: %d of %d URLs were skipped", report.Skipped, len(urls))
+	}
+
+	return report
+}
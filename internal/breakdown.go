@@ -0,0 +1,47 @@
+package internal
+
+// ScoreItem is a labeled number of points added to or taken off the score
+type ScoreItem struct {
+	Label  string `json:"label"`
+	Points int    `json:"points"`
+}
+
+// ScoreBreakdown explains a score as line items that sum to its Total
+type ScoreBreakdown struct {
+	Items []ScoreItem `json:"items"`
+	Total int         `json:"total"`
+}
+
+// newScoreBreakdown totals items, adding an adjustment when the penalties
+// would take the score below 0
+func newScoreBreakdown(items []ScoreItem) *ScoreBreakdown {
+	if total := sumScoreItems(items); total < 0 {
+		items = append(items, ScoreItem{Label: "Score floor of 0", Points: -total})
+	}
+	return &ScoreBreakdown{Items: items, Total: sumScoreItems(items)}
+}
+
+// sumScoreItems adds up the points of items
+func sumScoreItems(items []ScoreItem) int {
+	total := 0
+	for _, item := range items {
+		total += item.Points
+	}
+	return total
+}
+
+// deductionItems lists the penalties making up the deduction as negative
+// score items, skipping those that take nothing off
+func (r *AnalysisResult) deductionItems() []ScoreItem {
+	var items []ScoreItem
+	for _, penalty := range []ScoreItem{
+		{Label: "Information disclosure penalty", Points: -disclosureDeduction(r.Disclosures)},
+		{Label: "Insecure cookie penalty", Points: -cookieDeduction(r.Cookies)},
+		{Label: "CORS misconfiguration penalty", Points: -corsDeduction(r.CORS)},
+	} {
+		if penalty.Points != 0 {
+			items = append(items, penalty)
+		}
+	}
+	return items
+}
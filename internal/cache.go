@@ -0,0 +1,73 @@
+package internal
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultResultCacheTTL is how long an analysis is reused by default
+const DefaultResultCacheTTL = 5 * time.Minute
+
+// ResultCache reuses recent analyses of identical requests so repeated
+// calls neither re-fetch the target nor hammer it. It is safe for
+// concurrent use.
+type ResultCache struct {
+	ttl time.Duration
+
+	mu      sync.Mutex
+	entries map[string]*AnalysisResult
+}
+
+// NewResultCache creates a cache whose entries expire after ttl
+func NewResultCache(ttl time.Duration) *ResultCache {
+	return &ResultCache{ttl: ttl, entries: make(map[string]*AnalysisResult)}
+}
+
+// Get returns a copy of the result cached under key, marked as cached,
+// while it is younger than the TTL. A nil cache never hits.
+func (c *ResultCache) Get(key string) (*AnalysisResult, bool) {
+	if c == nil {
+		return nil, false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	result, ok := c.entries[key]
+	if !ok || time.Since(result.AnalyzedAt) >= c.ttl {
+		return nil, false
+	}
+	cached := *result
+	cached.Cached = true
+	return &cached, true
+}
+
+// Put caches a copy of result under key and drops expired entries.
+// Putting into a nil cache is a no-op.
+func (c *ResultCache) Put(key string, result *AnalysisResult) {
+	if c == nil || result == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for k, entry := range c.entries {
+		if time.Since(entry.AnalyzedAt) >= c.ttl {
+			delete(c.entries, k)
+		}
+	}
+	stored := *result
+	c.entries[key] = &stored
+}
+
+// CacheKey identifies an analysis request by its normalized URL and method
+// plus any variant, such as the output format or other options, that
+// changes the response
+func CacheKey(url, method string, variant ...string) string {
+	if normalized, err := normalizeURL(url); err == nil {
+		url = normalized
+	}
+	return strings.Join(append([]string{requestMethod(method), url}, variant...), "\n")
+}
@@ -0,0 +1,69 @@
+package internal
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"net/http"
+	"time"
+)
+
+// TLSInfo reports whether the certificate the target presented is trusted
+// and when it expires
+type TLSInfo struct {
+	Valid     bool      `json:"valid"`
+	Error     string    `json:"error,omitempty"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// CertificateError is returned when certificate validation is required and
+// the target presents a certificate that is not trusted
+type CertificateError struct {
+	URL string
+	Err error
+}
+
+func (e *CertificateError) Error() string {
+	return "untrusted certificate for " + e.URL + ": " + e.Err.Error()
+}
+
+func (e *CertificateError) Unwrap() error {
+	return e.Err
+}
+
+// certificateError wraps certificate verification failures in a
+// CertificateError and returns other errors unchanged
+func certificateError(url string, err error) error {
+	var verification *tls.CertificateVerificationError
+	if errors.As(err, &verification) {
+		return &CertificateError{URL: url, Err: verification.Err}
+	}
+	return err
+}
+
+// inspectTLS verifies the certificate chain of an HTTPS response against the
+// system roots and the requested host. The response is fetched without
+// verification, so problems are reported here instead of failing the
+// analysis. It returns nil for plain HTTP responses.
+func inspectTLS(resp *http.Response) *TLSInfo {
+	if resp.TLS == nil || len(resp.TLS.PeerCertificates) == 0 {
+		return nil
+	}
+
+	leaf := resp.TLS.PeerCertificates[0]
+	intermediates := x509.NewCertPool()
+	for _, cert := range resp.TLS.PeerCertificates[1:] {
+		intermediates.AddCert(cert)
+	}
+
+	info := &TLSInfo{Valid: true, ExpiresAt: leaf.NotAfter}
+	_, err := leaf.Verify(x509.VerifyOptions{
+		DNSName:       resp.Request.URL.Hostname(),
+		Intermediates: intermediates,
+	})
+	if err != nil {
+		info.Valid = false
+		info.Error = err.Error()
+	}
+	return info
+}
@@ -0,0 +1,130 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// HeaderChange describes a header present in both results whose value or
+// awarded weight differs
+type HeaderChange struct {
+	Name          string `json:"name"`
+	ValueBefore   string `json:"valueBefore"`
+	ValueAfter    string `json:"valueAfter"`
+	AwardedBefore int    `json:"awardedBefore"`
+	AwardedAfter  int    `json:"awardedAfter"`
+}
+
+// Comparison is the difference between two analyses
+type Comparison struct {
+	Added       []string       `json:"added"`
+	Removed     []string       `json:"removed"`
+	Changed     []HeaderChange `json:"changed"`
+	ScoreDelta  int            `json:"scoreDelta"`
+	GradeBefore string         `json:"gradeBefore"`
+	GradeAfter  string         `json:"gradeAfter"`
+}
+
+// Compare reports which checked headers were added, removed or changed
+// going from before to after, along with the score and grade movement
+func Compare(before, after *AnalysisResult) *Comparison {
+	comparison := &Comparison{
+		Added:       make([]string, 0),
+		Removed:     make([]string, 0),
+		Changed:     make([]HeaderChange, 0),
+		ScoreDelta:  after.Score - before.Score,
+		GradeBefore: before.Grade,
+		GradeAfter:  after.Grade,
+	}
+
+	previous := make(map[string]SecurityHeader, len(before.Summary))
+	for _, header := range before.Summary {
+		previous[header.Name] = header
+	}
+
+	seen := make(map[string]bool, len(after.Summary))
+	for _, header := range after.Summary {
+		seen[header.Name] = true
+		old, ok := previous[header.Name]
+
+		switch {
+		case header.Present && (!ok || !old.Present):
+			comparison.Added = append(comparison.Added, header.Name)
+		case !header.Present && ok && old.Present:
+			comparison.Removed = append(comparison.Removed, header.Name)
+		case header.Present && (old.Value != header.Value || old.Awarded != header.Awarded):
+			comparison.Changed = append(comparison.Changed, HeaderChange{
+				Name:          header.Name,
+				ValueBefore:   old.Value,
+				ValueAfter:    header.Value,
+				AwardedBefore: old.Awarded,
+				AwardedAfter:  header.Awarded,
+			})
+		}
+	}
+
+	for _, header := range before.Summary {
+		if header.Present && !seen[header.Name] {
+			comparison.Removed = append(comparison.Removed, header.Name)
+		}
+	}
+
+	return comparison
+}
+
+// PairComparison compares a staging deployment against production
+type PairComparison struct {
+	Staging    *AnalysisResult `json:"staging"`
+	Production *AnalysisResult `json:"production"`
+	Diff       *Comparison     `json:"diff"`
+	Warnings   []string        `json:"warnings"`
+}
+
+// ComparePair analyzes a staging and a production URL and reports where
+// staging's header posture differs, warning wherever staging is weaker
+func ComparePair(ctx context.Context, stagingURL, productionURL string) (*PairComparison, error) {
+	var (
+		wg                        sync.WaitGroup
+		staging, production       *AnalysisResult
+		stagingErr, productionErr error
+	)
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		staging, stagingErr = AnalyzeURLContext(ctx, stagingURL)
+	}()
+	go func() {
+		defer wg.Done()
+		production, productionErr = AnalyzeURLContext(ctx, productionURL)
+	}()
+	wg.Wait()
+
+	if stagingErr != nil {
+		return nil, fmt.Errorf("staging: %w", stagingErr)
+	}
+	if productionErr != nil {
+		return nil, fmt.Errorf("production: %w", productionErr)
+	}
+
+	pair := &PairComparison{
+		Staging:    staging,
+		Production: production,
+		Diff:       Compare(production, staging),
+		Warnings:   make([]string, 0),
+	}
+
+	if pair.Diff.ScoreDelta < 0 {
+		pair.Warnings = append(pair.Warnings, fmt.Sprintf("staging scores %d points lower than production (%d vs %d)", -pair.Diff.ScoreDelta, staging.Score, production.Score))
+	}
+	for _, name := range pair.Diff.Removed {
+		pair.Warnings = append(pair.Warnings, fmt.Sprintf("staging does not send %s, which production does", name))
+	}
+	for _, change := range pair.Diff.Changed {
+		if change.AwardedAfter < change.AwardedBefore {
+			pair.Warnings = append(pair.Warnings, fmt.Sprintf("staging's %s earns less credit than production's (%d vs %d)", change.Name, change.AwardedAfter, change.AwardedBefore))
+		}
+	}
+
+	return pair, nil
+}
@@ -0,0 +1,76 @@
+package internal
+
+// ComplianceControl is a framework control a security header helps satisfy
+type ComplianceControl struct {
+	Framework string `json:"framework"`
+	Control   string `json:"control"`
+	Title     string `json:"title"`
+}
+
+// ComplianceEntry lists the controls a checked header supports. Satisfied
+// is true when the header was present and earned its full weight.
+type ComplianceEntry struct {
+	Header    string              `json:"header"`
+	Present   bool                `json:"present"`
+	Satisfied bool                `json:"satisfied"`
+	Controls  []ComplianceControl `json:"controls"`
+}
+
+// Framework controls referenced by the compliance matrix
+var (
+	pciStrongCryptography = ComplianceControl{"PCI DSS v4.0", "4.2.1", "Strong cryptography protects cardholder data during transmission over open, public networks"}
+	pciCommonAttacks      = ComplianceControl{"PCI DSS v4.0", "6.2.4", "Software engineering techniques prevent or mitigate common software attacks"}
+	pciPaymentScripts     = ComplianceControl{"PCI DSS v4.0", "6.4.3", "Payment page scripts are authorized and their integrity is assured"}
+	pciTamperDetection    = ComplianceControl{"PCI DSS v4.0", "11.6.1", "Unauthorized changes to HTTP headers and payment page contents are detected"}
+
+	soc2BoundaryProtection = ComplianceControl{"SOC 2", "CC6.6", "Logical access security measures protect against threats from outside the system boundaries"}
+	soc2Transmission       = ComplianceControl{"SOC 2", "CC6.7", "Transmission of information is restricted and protected"}
+	soc2MaliciousSoftware  = ComplianceControl{"SOC 2", "CC6.8", "Unauthorized or malicious software is prevented or detected"}
+	soc2Confidentiality    = ComplianceControl{"SOC 2", "C1.1", "Confidential information is identified and maintained"}
+
+	isoCryptography = ComplianceControl{"ISO/IEC 27001:2022", "A.8.24", "Use of cryptography"}
+	isoAppSecurity  = ComplianceControl{"ISO/IEC 27001:2022", "A.8.26", "Application security requirements"}
+	isoSecureCoding = ComplianceControl{"ISO/IEC 27001:2022", "A.8.28", "Secure coding"}
+	isoPrivacy      = ComplianceControl{"ISO/IEC 27001:2022", "A.5.34", "Privacy and protection of PII"}
+)
+
+// complianceMatrix maps each checked header to the framework controls it
+// provides evidence for. Review it whenever a framework revision is adopted.
+var complianceMatrix = map[string][]ComplianceControl{
+	"Strict-Transport-Security":    {pciStrongCryptography, soc2Transmission, isoCryptography},
+	"X-Content-Type-Options":       {pciCommonAttacks, soc2MaliciousSoftware, isoSecureCoding},
+	"X-Frame-Options":              {pciCommonAttacks, soc2BoundaryProtection, isoSecureCoding},
+	"Content-Security-Policy":      {pciCommonAttacks, pciPaymentScripts, pciTamperDetection, soc2BoundaryProtection, soc2MaliciousSoftware, isoAppSecurity, isoSecureCoding},
+	"Referrer-Policy":              {soc2Confidentiality, isoPrivacy},
+	"Permissions-Policy":           {soc2BoundaryProtection, isoAppSecurity, isoPrivacy},
+	"Cross-Origin-Opener-Policy":   {pciCommonAttacks, isoAppSecurity},
+	"Cross-Origin-Resource-Policy": {pciCommonAttacks, isoAppSecurity},
+	"Cross-Origin-Embedder-Policy": {pciCommonAttacks, isoAppSecurity},
+	"Set-Login":                    {isoAppSecurity},
+}
+
+// ComplianceMatrix returns the controls supported by every checked header
+func ComplianceMatrix() []ComplianceEntry {
+	entries := make([]ComplianceEntry, 0, len(securityHeaders))
+	for _, header := range securityHeaders {
+		entries = append(entries, ComplianceEntry{
+			Header:   header.Name,
+			Controls: complianceMatrix[header.Name],
+		})
+	}
+	return entries
+}
+
+// complianceFor maps the summary of an analysis onto the compliance matrix
+func complianceFor(summary []SecurityHeader) []ComplianceEntry {
+	entries := make([]ComplianceEntry, 0, len(summary))
+	for _, header := range summary {
+		entries = append(entries, ComplianceEntry{
+			Header:    header.Name,
+			Present:   header.Present,
+			Satisfied: header.Present && header.Awarded == header.Weight,
+			Controls:  complianceMatrix[header.Name],
+		})
+	}
+	return entries
+}
@@ -0,0 +1,61 @@
+package internal
+
+import (
+	"fmt"
+	"net/http"
+	neturl "net/url"
+)
+
+// Confidence levels of an analysis
+const (
+	ConfidenceHigh   = "high"
+	ConfidenceMedium = "medium"
+	ConfidenceLow    = "low"
+)
+
+// minConfidentHeaders is the number of response headers below which the
+// response is suspected to come from a stub or error handler
+const minConfidentHeaders = 5
+
+// confidenceRank orders the levels from most to least confident
+var confidenceRank = map[string]int{
+	ConfidenceHigh:   0,
+	ConfidenceMedium: 1,
+	ConfidenceLow:    2,
+}
+
+// assessConfidence estimates how well the response represents the real
+// application. Redirects, error statuses and sparse responses lower the
+// level; each reason is returned as a note.
+func assessConfidence(url string, resp *http.Response) (string, []string) {
+	level := ConfidenceHigh
+	var notes []string
+	lower := func(to, note string) {
+		if confidenceRank[to] > confidenceRank[level] {
+			level = to
+		}
+		notes = append(notes, note)
+	}
+
+	switch status := resp.StatusCode; {
+	case status >= 300 && status < 400:
+		lower(ConfidenceMedium, fmt.Sprintf("status %d is a redirect; the headers of the final page were not analyzed", status))
+		if location, err := resp.Location(); err == nil {
+			if location.String() == url {
+				lower(ConfidenceLow, "the redirect points back at the analyzed URL, a redirect loop")
+			} else if page, err := neturl.Parse(url); err == nil && location.Host != page.Host {
+				lower(ConfidenceLow, fmt.Sprintf("the redirect leaves the host for %s, whose headers were not analyzed", location.Host))
+			}
+		}
+	case status == http.StatusForbidden || status == http.StatusTooManyRequests || status == http.StatusServiceUnavailable:
+		lower(ConfidenceLow, fmt.Sprintf("status %d suggests a WAF, rate limit or maintenance page answered instead of the application", status))
+	case status >= 400:
+		lower(ConfidenceLow, fmt.Sprintf("status %d means an error page was analyzed, which may send different headers than the application", status))
+	}
+
+	if count := len(resp.Header); count < minConfidentHeaders {
+		lower(ConfidenceMedium, fmt.Sprintf("only %d response headers were sent, which is typical of stub or error responses", count))
+	}
+
+	return level, notes
+}
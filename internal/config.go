@@ -0,0 +1,152 @@
+package internal
+
+import (
+	"fmt"
+	"net"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Config holds the tunable settings of the analyzer
+type Config struct {
+	// CriticalPenaltyMultiplier scales the score impact of missing critical
+	// headers. A value of 1 weighs them like any other header.
+	CriticalPenaltyMultiplier float64
+
+	// ServerTimingSeverity is the severity reported when Server-Timing
+	// exposes named backend components
+	ServerTimingSeverity Severity
+
+	// RedactedHeaders lists headers whose values are never included in
+	// results, such as credentials and cookies
+	RedactedHeaders []string
+
+	// RedactedPatterns mask the matching parts of any other header value
+	// included in results, such as API keys embedded in custom headers
+	RedactedPatterns []*regexp.Regexp
+
+	// XFrameSameOriginCredit is the share of the X-Frame-Options weight
+	// awarded for SAMEORIGIN; DENY always earns the full weight
+	XFrameSameOriginCredit float64
+
+	// RawRequestAllowedHosts lists the hosts hand-crafted raw requests may
+	// be sent to. Raw requests are refused entirely while it is empty.
+	RawRequestAllowedHosts []string
+
+	// RequiredPermissions lists the features a present Permissions-Policy
+	// must declare; each undeclared one is reported as an issue
+	RequiredPermissions []string
+
+	// SigningSecret is the HMAC key results are signed with on request.
+	// Signing is unavailable while it is empty.
+	SigningSecret string
+
+	// ClientTimeout bounds each outbound request unless an analysis
+	// overrides it
+	ClientTimeout time.Duration
+
+	// MaxConcurrentFetches caps the outbound requests in flight across all
+	// endpoints combined. Zero means unlimited.
+	MaxConcurrentFetches int
+
+	// FetchQueueTimeout is how long a fetch waits for a free slot once
+	// MaxConcurrentFetches is reached before failing
+	FetchQueueTimeout time.Duration
+
+	// AllowedNetworks are private networks targets may be fetched from
+	// anyway, for intranet deployments; every other private, loopback and
+	// link-local address is refused
+	AllowedNetworks []*net.IPNet
+
+	// HeaderWeights overrides the built-in weight of the named headers for
+	// every analysis
+	HeaderWeights map[string]int
+
+	// GradeLabels, when set, adds an alternative label derived from the
+	// score to every result alongside the letter grade
+	GradeLabels []GradeLabel
+
+	// GradeThresholds are the minimum scores of grades A to D
+	GradeThresholds GradeThresholds
+
+	// UserAgent identifies the analyzer on outbound requests that do not
+	// set their own User-Agent
+	UserAgent string
+}
+
+// DefaultClientTimeout bounds each outbound request unless configured otherwise
+const DefaultClientTimeout = 10 * time.Second
+
+// DefaultUserAgent is sent on outbound requests unless configured otherwise,
+// since some WAFs block Go's default User-Agent
+const DefaultUserAgent = "HTTP-Header-Security-Analyzer/1.0"
+
+// DefaultConfig returns the built-in analyzer settings
+func DefaultConfig() Config {
+	return Config{
+		CriticalPenaltyMultiplier: 1,
+		ServerTimingSeverity:      SeverityLow,
+		RedactedHeaders:           defaultRedactedHeaders,
+		RedactedPatterns:          defaultRedactedPatterns,
+		XFrameSameOriginCredit:    0.8,
+		RequiredPermissions:       defaultRequiredPermissions,
+		ClientTimeout:             DefaultClientTimeout,
+		FetchQueueTimeout:         DefaultFetchQueueTimeout,
+		GradeThresholds:           DefaultGradeThresholds,
+		UserAgent:                 DefaultUserAgent,
+	}
+}
+
+// config is the active analyzer configuration
+var config = DefaultConfig()
+
+// SetConfig validates and activates the given configuration.
+// It is meant to be called once at startup, before any analysis runs.
+func SetConfig(c Config) error {
+	if c.CriticalPenaltyMultiplier < 1 {
+		return fmt.Errorf("critical penalty multiplier must be at least 1, got %v", c.CriticalPenaltyMultiplier)
+	}
+	if !validSeverity(c.ServerTimingSeverity) {
+		return fmt.Errorf("unknown Server-Timing severity %q", c.ServerTimingSeverity)
+	}
+	if c.XFrameSameOriginCredit < 0 || c.XFrameSameOriginCredit > 1 {
+		return fmt.Errorf("X-Frame-Options SAMEORIGIN credit must be between 0 and 1, got %v", c.XFrameSameOriginCredit)
+	}
+	if c.ClientTimeout <= 0 {
+		return fmt.Errorf("client timeout must be positive, got %v", c.ClientTimeout)
+	}
+	if c.MaxConcurrentFetches < 0 {
+		return fmt.Errorf("max concurrent fetches must not be negative, got %d", c.MaxConcurrentFetches)
+	}
+	if c.FetchQueueTimeout <= 0 {
+		return fmt.Errorf("fetch queue timeout must be positive, got %v", c.FetchQueueTimeout)
+	}
+	if strings.TrimSpace(c.UserAgent) == "" {
+		return fmt.Errorf("user agent must not be empty")
+	}
+	if err := validateWeights(c.HeaderWeights); err != nil {
+		return err
+	}
+	if err := c.GradeThresholds.validate(); err != nil {
+		return err
+	}
+	labels := make([]GradeLabel, len(c.GradeLabels))
+	copy(labels, c.GradeLabels)
+	for _, label := range labels {
+		if label.MinScore < 0 || label.MinScore > 100 {
+			return fmt.Errorf("grade label %q minimum score must be between 0 and 100, got %d", label.Label, label.MinScore)
+		}
+		if label.Label == "" {
+			return fmt.Errorf("grade label for minimum score %d is empty", label.MinScore)
+		}
+	}
+	sort.SliceStable(labels, func(i, j int) bool { return labels[i].MinScore > labels[j].MinScore })
+	c.GradeLabels = labels
+
+	config = c
+	fetchSlots = newFetchSlots(c.MaxConcurrentFetches)
+	securityHeaders = applyWeights(defaultSecurityHeaders, c.HeaderWeights)
+	return nil
+}
@@ -0,0 +1,131 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Server types a configuration diff can be generated for
+const (
+	ServerNginx  = "nginx"
+	ServerCaddy  = "caddy"
+	ServerApache = "apache"
+)
+
+// ErrUnknownServer is returned for a server type without a config format
+var ErrUnknownServer = errors.New("config server must be one of nginx, caddy, apache")
+
+// headerDirectives render a single response header as a config line per
+// server type
+var headerDirectives = map[string]func(name, value string) string{
+	ServerNginx: func(name, value string) string {
+		return fmt.Sprintf("add_header %s %s always;", name, quoteConfigValue(value))
+	},
+	ServerCaddy: func(name, value string) string {
+		return fmt.Sprintf("\t%s %s", name, quoteConfigValue(value))
+	},
+	ServerApache: func(name, value string) string {
+		return fmt.Sprintf("Header always set %s %s", name, quoteConfigValue(value))
+	},
+}
+
+// ConfigChange is a header whose current directive should be replaced
+type ConfigChange struct {
+	Header  string `json:"header"`
+	Current string `json:"current"`
+	Value   string `json:"value"`
+	Reason  string `json:"reason,omitempty"`
+}
+
+// ConfigDiff lists the header directives to add and change for a server,
+// along with a unified-style diff of the config lines
+type ConfigDiff struct {
+	Server string         `json:"server"`
+	Add    []string       `json:"add"`
+	Change []ConfigChange `json:"change"`
+	Diff   string         `json:"diff"`
+}
+
+// ValidConfigServer reports whether a config diff can be generated for server
+func ValidConfigServer(server string) bool {
+	_, ok := headerDirectives[server]
+	return ok
+}
+
+// quoteConfigValue wraps a header value in double quotes
+func quoteConfigValue(value string) string {
+	return `"` + strings.ReplaceAll(value, `"`, `\"`) + `"`
+}
+
+// recommendedValue returns the value proposed for a checked header given
+// its current value. Headers without one, like the login-state dependent
+// Set-Login, are never proposed.
+func recommendedValue(name, current string) (string, bool) {
+	if name == "Permissions-Policy" {
+		return recommendedPermissionsPolicy(current), true
+	}
+	for _, header := range securityHeaders {
+		if header.Name == name && header.recommended != "" {
+			return header.recommended, true
+		}
+	}
+	return "", false
+}
+
+// recommendedPermissionsPolicy proposes a policy disabling the powerful,
+// required and tracking features, keeping whatever the current policy
+// already declares
+func recommendedPermissionsPolicy(current string) string {
+	policy := parsePermissionsPolicy(current)
+	directives := make([]string, 0)
+	if strings.TrimSpace(current) != "" {
+		directives = append(directives, strings.TrimSpace(current))
+	}
+	features := append(append([]string{}, powerfulFeatures...), config.RequiredPermissions...)
+	for _, feature := range append(features, trackingFeatures...) {
+		feature = strings.ToLower(feature)
+		if _, declared := policy[feature]; !declared {
+			policy[feature] = "()"
+			directives = append(directives, feature+"=()")
+		}
+	}
+	return strings.Join(directives, ", ")
+}
+
+// buildConfigDiff turns the summary of an analysis into the directives to
+// add for missing headers and to change for weak ones
+func buildConfigDiff(server string, summary []SecurityHeader) *ConfigDiff {
+	directive := headerDirectives[server]
+	diff := &ConfigDiff{Server: server, Add: make([]string, 0), Change: make([]ConfigChange, 0)}
+
+	var lines []string
+	for _, item := range summary {
+		recommended, ok := recommendedValue(item.Name, item.Value)
+		if !ok {
+			continue
+		}
+
+		switch {
+		case !item.Present:
+			line := directive(item.Name, recommended)
+			diff.Add = append(diff.Add, line)
+			lines = append(lines, "+"+line)
+		case item.Awarded < item.Weight || item.Name == "Permissions-Policy" && item.Value != recommended:
+			change := ConfigChange{Header: item.Name, Current: item.Value, Value: recommended}
+			if len(item.Issues) > 0 {
+				change.Reason = strings.Join(item.Issues, "; ")
+			}
+			diff.Change = append(diff.Change, change)
+			lines = append(lines, "-"+directive(item.Name, item.Value), "+"+directive(item.Name, recommended))
+		}
+	}
+
+	if server == ServerCaddy && len(lines) > 0 {
+		lines = append(append([]string{" header {"}, lines...), " }")
+	}
+	if len(lines) > 0 {
+		diff.Diff = strings.Join(lines, "\n") + "\n"
+	}
+	return diff
+}
@@ -0,0 +1,143 @@
+package internal
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// cookiePenalties is the number of points the worst cookie finding takes
+// off the score, by severity
+var cookiePenalties = map[Severity]int{
+	SeverityLow:    1,
+	SeverityMedium: 3,
+	SeverityHigh:   5,
+}
+
+// sessionCookieMarkers are name fragments of cookies that typically carry a
+// session or credential
+var sessionCookieMarkers = []string{"sess", "sid", "auth", "token", "jwt", "login"}
+
+// sessionLikeCookie reports whether a cookie likely carries a session: it
+// is set by an authenticated endpoint or its name suggests so
+func sessionLikeCookie(name string, authenticated *bool) bool {
+	if authenticated != nil && *authenticated {
+		return true
+	}
+	name = strings.ToLower(name)
+	for _, marker := range sessionCookieMarkers {
+		if strings.Contains(name, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// CookieFinding reports the security attributes of a cookie the response
+// sets. Cookie values are never included.
+type CookieFinding struct {
+	Name     string   `json:"name"`
+	Secure   bool     `json:"secure"`
+	HttpOnly bool     `json:"httpOnly"`
+	SameSite string   `json:"sameSite,omitempty"`
+	Domain   string   `json:"domain,omitempty"`
+	Path     string   `json:"path,omitempty"`
+	Severity Severity `json:"severity"`
+	Issues   []string `json:"issues,omitempty"`
+
+	// Cleared is set for cookies sent without a value or already expired,
+	// which only delete a cookie and are not evaluated
+	Cleared bool `json:"cleared,omitempty"`
+}
+
+// cookieSeverity is the severity of a cookie missing security attributes.
+// Cookies on authenticated endpoints are likely session cookies and are
+// escalated; on anonymous pages they are likely tracking cookies and
+// downgraded. When unknown they sit at a moderate baseline.
+func cookieSeverity(authenticated *bool) Severity {
+	switch {
+	case authenticated == nil:
+		return SeverityMedium
+	case *authenticated:
+		return SeverityHigh
+	default:
+		return SeverityLow
+	}
+}
+
+// sameSiteName returns the SameSite attribute as written in Set-Cookie
+func sameSiteName(mode http.SameSite) string {
+	switch mode {
+	case http.SameSiteLaxMode:
+		return "Lax"
+	case http.SameSiteStrictMode:
+		return "Strict"
+	case http.SameSiteNoneMode:
+		return "None"
+	default:
+		return ""
+	}
+}
+
+// checkCookies evaluates every cookie set by resp for the Secure, HttpOnly
+// and SameSite attributes, for a Domain sharing it with subdomains and, for
+// session-like cookies, for a Path=/ sending it to every path
+func checkCookies(resp *http.Response, authenticated *bool) []CookieFinding {
+	findings := make([]CookieFinding, 0)
+	for _, cookie := range resp.Cookies() {
+		finding := CookieFinding{
+			Name:     cookie.Name,
+			Secure:   cookie.Secure,
+			HttpOnly: cookie.HttpOnly,
+			SameSite: sameSiteName(cookie.SameSite),
+			Domain:   cookie.Domain,
+			Path:     cookie.Path,
+			Severity: SeverityNone,
+		}
+		if cookieCleared(cookie) {
+			finding.Cleared = true
+			findings = append(findings, finding)
+			continue
+		}
+
+		if !cookie.Secure {
+			finding.Issues = append(finding.Issues, "missing Secure, so the cookie is also sent over plain HTTP")
+		}
+		if !cookie.HttpOnly {
+			finding.Issues = append(finding.Issues, "missing HttpOnly, so scripts can read the cookie")
+		}
+		switch {
+		case finding.SameSite == "":
+			finding.Issues = append(finding.Issues, "missing SameSite, so the cookie relies on browser defaults for cross-site requests")
+		case cookie.SameSite == http.SameSiteNoneMode && !cookie.Secure:
+			finding.Issues = append(finding.Issues, "SameSite=None without Secure is rejected by browsers")
+		}
+		if cookie.Domain != "" {
+			finding.Issues = append(finding.Issues, fmt.Sprintf("Domain=%s shares the cookie with every subdomain", cookie.Domain))
+		}
+		if cookie.Path == "/" && sessionLikeCookie(cookie.Name, authenticated) {
+			finding.Issues = append(finding.Issues, "Path=/ sends the session cookie to every path on the host; scope it to the paths that need it")
+		}
+		if len(finding.Issues) > 0 {
+			finding.Severity = cookieSeverity(authenticated)
+		}
+		findings = append(findings, finding)
+	}
+	return findings
+}
+
+// cookieCleared reports whether a Set-Cookie only deletes a cookie
+func cookieCleared(cookie *http.Cookie) bool {
+	return cookie.Value == "" || cookie.MaxAge < 0 ||
+		!cookie.Expires.IsZero() && cookie.Expires.Before(time.Now())
+}
+
+// cookieDeduction is the score penalty for the worst cookie finding
+func cookieDeduction(findings []CookieFinding) int {
+	worst := 0
+	for _, finding := range findings {
+		worst = max(worst, cookiePenalties[finding.Severity])
+	}
+	return worst
+}
@@ -0,0 +1,45 @@
+package internal
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestCheckCookiesBroadScope(t *testing.T) {
+	const secure = "; Secure; HttpOnly; SameSite=Lax"
+	yes, no := true, false
+	tests := []struct {
+		name          string
+		setCookie     string
+		authenticated *bool
+		issue         string
+	}{
+		{"session cookie on every path", "sessionid=abc; Path=/" + secure, nil, "Path=/"},
+		{"authenticated cookie on every path", "prefs=1; Path=/" + secure, &yes, "Path=/"},
+		{"tracking cookie on every path", "prefs=1; Path=/" + secure, &no, ""},
+		{"session cookie on a narrow path", "sessionid=abc; Path=/app" + secure, nil, ""},
+		{"cookie shared with subdomains", "prefs=1; Domain=example.com" + secure, nil, "Domain=example.com"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := &http.Response{Header: http.Header{"Set-Cookie": {tt.setCookie}}}
+			findings := checkCookies(resp, tt.authenticated)
+			if len(findings) != 1 {
+				t.Fatalf("got %d findings, want 1", len(findings))
+			}
+
+			found := slices.ContainsFunc(findings[0].Issues, func(issue string) bool {
+				return tt.issue != "" && strings.HasPrefix(issue, tt.issue)
+			})
+			switch {
+			case tt.issue == "" && len(findings[0].Issues) > 0:
+				t.Errorf("unexpected issues %q", findings[0].Issues)
+			case tt.issue != "" && !found:
+				t.Errorf("issues %q do not include %q", findings[0].Issues, tt.issue)
+			}
+		})
+	}
+}
@@ -0,0 +1,203 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// corsPenalties is the number of points a CORS finding takes off the
+// score, by severity; the findings cost at most maxCORSPenalty together
+var corsPenalties = map[Severity]int{
+	SeverityLow:    1,
+	SeverityMedium: 5,
+	SeverityHigh:   10,
+}
+
+const maxCORSPenalty = 10
+
+// corsHeaders are the response headers that make up a CORS policy
+var corsHeaders = []string{
+	"Access-Control-Allow-Origin",
+	"Access-Control-Allow-Credentials",
+	"Access-Control-Allow-Methods",
+	"Access-Control-Allow-Headers",
+	"Access-Control-Expose-Headers",
+	"Access-Control-Max-Age",
+}
+
+// PreflightOptions describes the cross-origin request to simulate
+type PreflightOptions struct {
+	Origin  string   `json:"origin" openapi:"required"`
+	Method  string   `json:"method"`
+	Headers []string `json:"headers,omitempty"`
+}
+
+// PreflightResult is the outcome of a CORS preflight request
+type PreflightResult struct {
+	Origin     string            `json:"origin"`
+	Method     string            `json:"method"`
+	StatusCode int               `json:"statusCode,omitempty"`
+	Headers    map[string]string `json:"headers,omitempty"`
+	Allowed    bool              `json:"allowed"`
+	Issues     []string          `json:"issues,omitempty"`
+	Error      string            `json:"error,omitempty"`
+}
+
+// runPreflight sends an OPTIONS request announcing a cross-origin request
+// from opts.Origin and analyzes the CORS headers of the response.
+// Failures are reported in the result instead of failing the analysis.
+func runPreflight(ctx context.Context, client *http.Client, url string, opts PreflightOptions) *PreflightResult {
+	result := &PreflightResult{
+		Origin: opts.Origin,
+		Method: strings.ToUpper(opts.Method),
+	}
+	if result.Method == "" {
+		result.Method = http.MethodGet
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodOptions, url, nil)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	req.Header.Set("Origin", opts.Origin)
+	req.Header.Set("Access-Control-Request-Method", result.Method)
+	if len(opts.Headers) > 0 {
+		req.Header.Set("Access-Control-Request-Headers", strings.Join(opts.Headers, ", "))
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	defer closeBody(resp)
+
+	result.StatusCode = resp.StatusCode
+	result.Headers = make(map[string]string)
+	for _, name := range corsHeaders {
+		if value := resp.Header.Get(name); value != "" {
+			result.Headers[name] = redactValue(name, value)
+		}
+	}
+
+	result.Issues = corsIssues(resp.Header, opts.Origin)
+	result.Allowed = resp.StatusCode >= 200 && resp.StatusCode < 300 &&
+		originAllowed(resp.Header, opts.Origin) &&
+		methodAllowed(resp.Header, result.Method)
+
+	return result
+}
+
+// corsIssues flags risky patterns in a CORS policy
+func corsIssues(header http.Header, origin string) []string {
+	var issues []string
+	for _, finding := range detectCORS(header, origin) {
+		issues = append(issues, finding.Issues...)
+	}
+	return issues
+}
+
+// detectCORS reports risky patterns in the CORS policy of a response.
+// origin is the Origin the request was sent with, if any, to recognize an
+// echoed origin. A wildcard origin without credentials is normal for public
+// resources and is noted without a penalty.
+func detectCORS(header http.Header, origin string) []SecurityHeader {
+	findings := make([]SecurityHeader, 0)
+
+	allowOrigin := strings.TrimSpace(header.Get("Access-Control-Allow-Origin"))
+	credentials := strings.EqualFold(strings.TrimSpace(header.Get("Access-Control-Allow-Credentials")), "true")
+	if allowOrigin != "" {
+		finding := SecurityHeader{
+			Name:        "Access-Control-Allow-Origin",
+			Present:     true,
+			Description: "Names the origins allowed to read the response.",
+			Value:       allowOrigin,
+		}
+		switch {
+		case allowOrigin == "*" && credentials:
+			finding.Severity = SeverityHigh
+			finding.Issues = []string{"any origin is allowed together with credentials"}
+		case allowOrigin == "null":
+			finding.Severity = SeverityMedium
+			if credentials {
+				finding.Severity = SeverityHigh
+			}
+			finding.Issues = []string{`the "null" origin is allowed, which sandboxed iframes and local files can use`}
+		case origin != "" && allowOrigin == origin && credentials:
+			finding.Severity = SeverityMedium
+			finding.Issues = []string{fmt.Sprintf("the request origin %q is echoed back with credentials allowed; if arbitrary origins are echoed, any site can read authenticated responses", origin)}
+		case allowOrigin == "*":
+			finding.Severity = SeverityLow
+			finding.Issues = []string{"any origin may read the response, which is only safe for public resources"}
+		}
+		if finding.Issues != nil {
+			// a public wildcard without credentials costs nothing
+			if allowOrigin != "*" || credentials {
+				finding.Penalty = corsPenalties[finding.Severity]
+			}
+			findings = append(findings, finding)
+		}
+	}
+
+	for _, wildcard := range []struct{ name, description, issue string }{
+		{"Access-Control-Allow-Methods", "Names the methods cross-origin requests may use.", "any request method is allowed"},
+		{"Access-Control-Allow-Headers", "Names the headers cross-origin requests may send.", "any request header is allowed"},
+	} {
+		if strings.TrimSpace(header.Get(wildcard.name)) == "*" {
+			findings = append(findings, SecurityHeader{
+				Name:        wildcard.name,
+				Present:     true,
+				Description: wildcard.description,
+				Value:       "*",
+				Severity:    SeverityLow,
+				Penalty:     corsPenalties[SeverityLow],
+				Issues:      []string{wildcard.issue},
+			})
+		}
+	}
+
+	return findings
+}
+
+// requestOrigin returns the Origin header the response was requested with
+func requestOrigin(resp *http.Response) string {
+	if resp.Request == nil {
+		return ""
+	}
+	return resp.Request.Header.Get("Origin")
+}
+
+// corsDeduction is the score penalty for the CORS findings
+func corsDeduction(findings []SecurityHeader) int {
+	total := 0
+	for _, finding := range findings {
+		total += finding.Penalty
+	}
+	return min(total, maxCORSPenalty)
+}
+
+// originAllowed reports whether the policy admits requests from origin
+func originAllowed(header http.Header, origin string) bool {
+	allowed := header.Get("Access-Control-Allow-Origin")
+	return allowed == "*" || allowed == origin
+}
+
+// methodAllowed reports whether the policy admits the given method.
+// CORS-safelisted methods never need to be listed explicitly.
+func methodAllowed(header http.Header, method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost:
+		return true
+	}
+
+	for _, allowed := range strings.Split(header.Get("Access-Control-Allow-Methods"), ",") {
+		allowed = strings.TrimSpace(allowed)
+		if allowed == "*" || strings.EqualFold(allowed, method) {
+			return true
+		}
+	}
+	return false
+}
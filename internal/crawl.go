@@ -0,0 +1,185 @@
+package internal
+
+import (
+	"context"
+	"math"
+	"net/http"
+	neturl "net/url"
+	"path"
+	"regexp"
+	"strings"
+)
+
+const (
+	// DefaultCrawlPages is how many pages a crawl visits unless told otherwise
+	DefaultCrawlPages = 10
+	// MaxCrawlPages caps how many pages a single crawl may visit
+	MaxCrawlPages = 50
+)
+
+// anchorTag matches the href of an <a> element
+var anchorTag = regexp.MustCompile(`(?is)<a\b[^>]*?\bhref\s*=\s*["']?([^"'\s>]+)`)
+
+// skippedExtensions are links to files that are not pages
+var skippedExtensions = map[string]bool{
+	".css": true, ".js": true, ".png": true, ".jpg": true, ".jpeg": true,
+	".gif": true, ".svg": true, ".ico": true, ".webp": true, ".woff": true,
+	".woff2": true, ".pdf": true, ".zip": true, ".mp4": true,
+}
+
+// CrawlPage is the analysis of a single crawled page
+type CrawlPage struct {
+	URL     string          `json:"url"`
+	Score   int             `json:"score"`
+	Grade   string          `json:"grade,omitempty"`
+	Headers map[string]bool `json:"headers,omitempty"`
+	Error   string          `json:"error,omitempty"`
+}
+
+// HeaderConsistency describes how uniformly a header is applied across
+// the crawled pages. InconsistencyRatio is the share of pages in the
+// minority (0 when uniform, at most 0.5).
+type HeaderConsistency struct {
+	Header             string   `json:"header"`
+	PresentOn          int      `json:"presentOn"`
+	MissingOn          int      `json:"missingOn"`
+	Consistent         bool     `json:"consistent"`
+	InconsistencyRatio float64  `json:"inconsistencyRatio"`
+	MissingURLs        []string `json:"missingUrls,omitempty"`
+}
+
+// CrawlReport is the per-page analysis of a crawl and the per-header
+// consistency across the pages that could be analyzed
+type CrawlReport struct {
+	Start        string              `json:"start"`
+	Pages        []CrawlPage         `json:"pages"`
+	Consistency  []HeaderConsistency `json:"consistency"`
+	Inconsistent []string            `json:"inconsistent"`
+}
+
+// Crawl analyzes up to maxPages pages of the start URL's host, following
+// same-host links breadth first, and aggregates header presence across them
+func Crawl(ctx context.Context, start string, maxPages int) (*CrawlReport, error) {
+	start, err := normalizeURL(start)
+	if err != nil {
+		return nil, err
+	}
+	origin, err := neturl.Parse(start)
+	if err != nil {
+		return nil, err
+	}
+	if maxPages <= 0 {
+		maxPages = DefaultCrawlPages
+	}
+	if maxPages > MaxCrawlPages {
+		maxPages = MaxCrawlPages
+	}
+
+	client := newClient(sharedTransport, 0)
+	report := &CrawlReport{Start: start, Pages: make([]CrawlPage, 0)}
+	queue := []string{start}
+	seen := map[string]bool{start: true}
+
+	for len(queue) > 0 && len(report.Pages) < maxPages && ctx.Err() == nil {
+		page, links := crawlPage(ctx, client, queue[0])
+		queue = queue[1:]
+		report.Pages = append(report.Pages, page)
+
+		for _, link := range links {
+			if link.Host == origin.Host && !seen[link.String()] {
+				seen[link.String()] = true
+				queue = append(queue, link.String())
+			}
+		}
+	}
+
+	report.Consistency = headerConsistency(report.Pages)
+	report.Inconsistent = make([]string, 0)
+	for _, header := range report.Consistency {
+		if !header.Consistent {
+			report.Inconsistent = append(report.Inconsistent, header.Header)
+		}
+	}
+	return report, nil
+}
+
+// crawlPage analyzes a page and returns the page links it points to,
+// including the target of a redirect
+func crawlPage(ctx context.Context, client *http.Client, url string) (CrawlPage, []*neturl.URL) {
+	page := CrawlPage{URL: url}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		page.Error = err.Error()
+		return page, nil
+	}
+	requestCompression(req)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		page.Error = err.Error()
+		return page, nil
+	}
+	defer resp.Body.Close()
+
+	body := readBody(resp)
+	scored := scoreResponse(url, securityHeaders, resp, nil)
+	page.Score = scored.Score
+	page.Grade = scored.Grade
+	page.Headers = scored.Headers
+
+	refs := make([]string, 0)
+	if location := resp.Header.Get("Location"); location != "" {
+		refs = append(refs, location)
+	}
+	if strings.Contains(resp.Header.Get("Content-Type"), "html") {
+		for _, match := range anchorTag.FindAllStringSubmatch(string(body), -1) {
+			refs = append(refs, match[1])
+		}
+	}
+
+	links := make([]*neturl.URL, 0, len(refs))
+	for _, ref := range refs {
+		link, err := resp.Request.URL.Parse(ref)
+		if err != nil || (link.Scheme != "http" && link.Scheme != "https") {
+			continue
+		}
+		if skippedExtensions[strings.ToLower(path.Ext(link.Path))] {
+			continue
+		}
+		link.Fragment = ""
+		links = append(links, link)
+	}
+	return page, links
+}
+
+// headerConsistency aggregates the presence of each checked header across
+// the pages that were analyzed successfully
+func headerConsistency(pages []CrawlPage) []HeaderConsistency {
+	consistency := make([]HeaderConsistency, 0, len(securityHeaders))
+	for _, header := range securityHeaders {
+		entry := HeaderConsistency{Header: header.Name}
+		for _, page := range pages {
+			if page.Error != "" {
+				continue
+			}
+			if page.Headers[header.Name] {
+				entry.PresentOn++
+			} else {
+				entry.MissingOn++
+				entry.MissingURLs = append(entry.MissingURLs, page.URL)
+			}
+		}
+
+		entry.Consistent = entry.PresentOn == 0 || entry.MissingOn == 0
+		if entry.Consistent {
+			// a header missing everywhere is a gap, not drift
+			entry.MissingURLs = nil
+		} else {
+			minority := min(entry.PresentOn, entry.MissingOn)
+			entry.InconsistencyRatio = math.Round(float64(minority)*100/float64(entry.PresentOn+entry.MissingOn)) / 100
+		}
+		consistency = append(consistency, entry)
+	}
+	return consistency
+}
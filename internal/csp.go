@@ -0,0 +1,266 @@
+package internal
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// cspWeakness is a dangerous construct found in a CSP and the share of the
+// weight it costs
+type cspWeakness struct {
+	issue   string
+	penalty float64
+}
+
+// cspReportOnlyCredit is the share of the weight a policy earns when it is
+// only sent as Content-Security-Policy-Report-Only
+const cspReportOnlyCredit = 0.5
+
+// cspNonceCredit is the share of the weight a nonce or hash based script
+// policy wins back from its weaknesses
+const cspNonceCredit = 0.15
+
+// cspWildcardSources allow scripts or plugins from practically anywhere
+var cspWildcardSources = []string{"*", "http:", "https:", "http://*", "https://*"}
+
+// cspPolicy maps each CSP directive name to its source list
+type cspPolicy map[string][]string
+
+// parseCSP splits a Content-Security-Policy value into its directives.
+// Directive names are lowercased; when a directive is repeated only the
+// first occurrence counts, as in browsers.
+func parseCSP(value string) cspPolicy {
+	policy := make(cspPolicy)
+	for _, directive := range strings.Split(value, ";") {
+		fields := strings.Fields(directive)
+		if len(fields) == 0 {
+			continue
+		}
+		name := strings.ToLower(fields[0])
+		if _, seen := policy[name]; seen {
+			continue
+		}
+		policy[name] = fields[1:]
+	}
+	return policy
+}
+
+// cspValue returns the enforced policy, falling back to the report-only one
+func cspValue(header http.Header) string {
+	if value := header.Get("Content-Security-Policy"); value != "" {
+		return value
+	}
+	return header.Get("Content-Security-Policy-Report-Only")
+}
+
+// scriptSources returns the directive governing scripts and its source
+// list: script-src, falling back to default-src
+func (p cspPolicy) scriptSources() (string, []string, bool) {
+	if sources, ok := p["script-src"]; ok {
+		return "script-src", sources, true
+	}
+	if sources, ok := p["default-src"]; ok {
+		return "default-src", sources, true
+	}
+	return "", nil, false
+}
+
+// checkCSP runs the Content-Security-Policy value checks
+func checkCSP(item *SecurityHeader, header http.Header) {
+	checkCSPDataScripts(item, header)
+	checkCSPDirectives(item, header)
+	checkCSPWildcardSubdomains(item, header)
+	checkCSPNonces(item, header)
+}
+
+// checkCSPNonces notes a script policy built on nonces or hashes rather
+// than host allowlists, and that browsers then ignore 'unsafe-inline'.
+// The reward itself is applied by checkCSPDirectives.
+func checkCSPNonces(item *SecurityHeader, header http.Header) {
+	directive, sources, ok := parseCSP(cspValue(header)).scriptSources()
+	if !ok || !cspHasNonceOrHash(sources) {
+		return
+	}
+
+	item.Notes = append(item.Notes, fmt.Sprintf("%s allows scripts by %s, which is far stronger than a host allowlist", directive, cspNonceKinds(sources)))
+	if cspHasSource(sources, "'strict-dynamic'") {
+		item.Notes = append(item.Notes, fmt.Sprintf("%s uses 'strict-dynamic', so trusted scripts may load others while host sources are ignored", directive))
+	}
+	if cspHasSource(sources, "'unsafe-inline'") {
+		item.Notes = append(item.Notes, fmt.Sprintf("%s allows 'unsafe-inline', but browsers ignore it because a nonce or hash is present", directive))
+	}
+}
+
+// cspNonceKinds describes which of nonces and hashes a source list uses
+func cspNonceKinds(sources []string) string {
+	nonce, hash := false, false
+	for _, source := range sources {
+		lower := strings.ToLower(source)
+		switch {
+		case strings.HasPrefix(lower, "'nonce-"):
+			nonce = true
+		case cspHasNonceOrHash([]string{lower}):
+			hash = true
+		}
+	}
+	switch {
+	case nonce && hash:
+		return "nonce and hash"
+	case nonce:
+		return "nonce"
+	default:
+		return "hash"
+	}
+}
+
+// checkCSPWildcardSubdomains reports every *.example.com style source with
+// medium severity: any compromised subdomain can serve allowed content.
+// It does not affect the awarded weight.
+func checkCSPWildcardSubdomains(item *SecurityHeader, header http.Header) {
+	policy := parseCSP(cspValue(header))
+
+	names := make([]string, 0, len(policy))
+	for name := range policy {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		for _, source := range policy[name] {
+			if !cspWildcardSubdomain(source) {
+				continue
+			}
+			item.Issues = append(item.Issues, fmt.Sprintf("%s allows the wildcard subdomain source %s; list the specific subdomains instead", name, source))
+			if item.Severity != SeverityHigh {
+				item.Severity = SeverityMedium
+			}
+		}
+	}
+}
+
+// cspWildcardSubdomain reports whether a host source matches any subdomain,
+// such as *.example.com or https://*.example.com
+func cspWildcardSubdomain(source string) bool {
+	if _, rest, found := strings.Cut(source, "://"); found {
+		source = rest
+	}
+	return strings.HasPrefix(source, "*.")
+}
+
+// checkCSPDirectives lowers the awarded weight for each weakness in the
+// policy, gives some of it back when scripts are allowed by nonce or hash,
+// and halves it again when the policy is only reported, not enforced
+func checkCSPDirectives(item *SecurityHeader, header http.Header) {
+	policy := parseCSP(cspValue(header))
+
+	penalty := 0.0
+	for _, weakness := range policy.weaknesses() {
+		penalty += weakness.penalty
+		item.Issues = append(item.Issues, weakness.issue)
+	}
+	if _, sources, ok := policy.scriptSources(); ok && cspHasNonceOrHash(sources) {
+		penalty -= cspNonceCredit
+	}
+	credit := math.Min(1, math.Max(0, 1-penalty))
+
+	if header.Get("Content-Security-Policy") == "" {
+		credit *= cspReportOnlyCredit
+		item.Issues = append(item.Issues, "policy is report-only and is not enforced")
+	}
+
+	if awarded := int(math.Round(float64(item.Weight) * credit)); awarded < item.Awarded {
+		item.Awarded = awarded
+	}
+}
+
+// weaknesses lists the dangerous constructs in the policy: inline or eval'd
+// scripts, wildcard script or plugin sources, and missing default-src or
+// object-src
+func (p cspPolicy) weaknesses() []cspWeakness {
+	var found []cspWeakness
+
+	if _, ok := p["default-src"]; !ok {
+		found = append(found, cspWeakness{"missing default-src, so undeclared resource types are unrestricted", 0.15})
+	}
+	if _, ok := p["object-src"]; !ok && !cspOnlyNone(p["default-src"]) {
+		found = append(found, cspWeakness{"missing object-src 'none', so plugins may be loaded", 0.1})
+	}
+	if wildcard := cspWildcard(p["object-src"]); wildcard != "" {
+		found = append(found, cspWeakness{fmt.Sprintf("object-src allows the wildcard source %s", wildcard), 0.3})
+	}
+
+	directive, sources, ok := p.scriptSources()
+	if !ok {
+		return found
+	}
+	if cspHasSource(sources, "'unsafe-inline'") && !cspHasNonceOrHash(sources) {
+		found = append(found, cspWeakness{fmt.Sprintf("%s allows 'unsafe-inline', letting injected inline scripts run", directive), 0.3})
+	}
+	if cspHasSource(sources, "'unsafe-eval'") {
+		found = append(found, cspWeakness{fmt.Sprintf("%s allows 'unsafe-eval', letting strings be evaluated as code", directive), 0.15})
+	}
+	if wildcard := cspWildcard(sources); wildcard != "" {
+		found = append(found, cspWeakness{fmt.Sprintf("%s allows the wildcard source %s", directive, wildcard), 0.3})
+	}
+	return found
+}
+
+// cspHasSource reports whether a source list contains the given source
+func cspHasSource(sources []string, source string) bool {
+	for _, candidate := range sources {
+		if strings.EqualFold(candidate, source) {
+			return true
+		}
+	}
+	return false
+}
+
+// cspWildcard returns the first wildcard source in a source list
+func cspWildcard(sources []string) string {
+	for _, wildcard := range cspWildcardSources {
+		if cspHasSource(sources, wildcard) {
+			return wildcard
+		}
+	}
+	return ""
+}
+
+// cspHasNonceOrHash reports whether a source list contains a nonce or hash,
+// which makes CSP2 browsers ignore 'unsafe-inline'
+func cspHasNonceOrHash(sources []string) bool {
+	for _, source := range sources {
+		lower := strings.ToLower(source)
+		for _, prefix := range []string{"'nonce-", "'sha256-", "'sha384-", "'sha512-"} {
+			if strings.HasPrefix(lower, prefix) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// cspOnlyNone reports whether a source list is exactly 'none'
+func cspOnlyNone(sources []string) bool {
+	return len(sources) == 1 && strings.EqualFold(sources[0], "'none'")
+}
+
+// checkCSPDataScripts forfeits the CSP credit when scripts may be loaded
+// from data: URIs, a well-known XSS bypass
+func checkCSPDataScripts(item *SecurityHeader, header http.Header) {
+	directive, sources, ok := parseCSP(cspValue(header)).scriptSources()
+	if !ok {
+		return
+	}
+
+	for _, source := range sources {
+		if strings.EqualFold(source, "data:") {
+			item.Awarded = 0
+			item.Severity = SeverityHigh
+			item.Issues = append(item.Issues, fmt.Sprintf("%s allows %s, letting injected data: URIs run as scripts", directive, source))
+			return
+		}
+	}
+}
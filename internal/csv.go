@@ -0,0 +1,57 @@
+package internal
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+)
+
+var csvHeader = []string{"url", "header", "present", "severity", "value"}
+
+// csvRows converts an analysis result into one CSV row per checked header
+// and per disclosure
+func csvRows(url string, result *AnalysisResult) [][]string {
+	headers := make([]SecurityHeader, 0, len(result.Summary)+len(result.Disclosures))
+	headers = append(headers, result.Summary...)
+	headers = append(headers, result.Disclosures...)
+
+	rows := make([][]string, 0, len(headers))
+	for _, header := range headers {
+		severity := header.Severity
+		if severity == "" {
+			severity = SeverityNone
+		}
+		rows = append(rows, []string{
+			url,
+			header.Name,
+			strconv.FormatBool(header.Present),
+			string(severity),
+			header.Value,
+		})
+	}
+	return rows
+}
+
+// WriteBatchCSV writes every finding of a batch as a single CSV document.
+// URLs that failed to analyze are written as a single "error" row.
+func WriteBatchCSV(w io.Writer, items []BatchItem) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(csvHeader); err != nil {
+		return err
+	}
+
+	for _, item := range items {
+		if item.Result == nil {
+			if err := writer.Write([]string{item.URL, "", "", "error", item.Error}); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := writer.WriteAll(csvRows(item.URL, item.Result)); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
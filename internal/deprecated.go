@@ -0,0 +1,43 @@
+package internal
+
+import (
+	"net/http"
+	"strings"
+)
+
+// deprecatedHeaders are obsolete headers that should be removed or
+// replaced; they are reported without affecting the score
+var deprecatedHeaders = []struct {
+	name        string
+	description string
+	issue       string
+}{
+	{"X-XSS-Protection", "Controlled the XSS auditor that browsers have removed.", "the XSS auditor it enables was removed from browsers and could itself be abused to leak data; remove the header or send 0, and rely on Content-Security-Policy"},
+	{"Expect-CT", "Enforced Certificate Transparency, which browsers now require by default.", "Certificate Transparency is enforced by default and browsers ignore Expect-CT; remove the header"},
+	{"Public-Key-Pins", "Pinned certificate keys (HPKP), which browsers no longer support.", "HPKP was removed from browsers because a bad pin could lock users out of the site; remove the header"},
+	{"Public-Key-Pins-Report-Only", "Reported certificate key pin violations (HPKP).", "HPKP was removed from browsers; remove the header"},
+	{"Feature-Policy", "Predecessor of Permissions-Policy.", "Feature-Policy is superseded by Permissions-Policy; send Permissions-Policy instead"},
+}
+
+// detectDeprecated flags deprecated headers in the response.
+// X-XSS-Protection: 0, which disables the auditor, is the recommended
+// setting and is not flagged.
+func detectDeprecated(header http.Header) []SecurityHeader {
+	findings := make([]SecurityHeader, 0)
+	for _, deprecated := range deprecatedHeaders {
+		value := strings.TrimSpace(header.Get(deprecated.name))
+		if value == "" || deprecated.name == "X-XSS-Protection" && value == "0" {
+			continue
+		}
+
+		findings = append(findings, SecurityHeader{
+			Name:        deprecated.name,
+			Present:     true,
+			Description: deprecated.description,
+			Value:       value,
+			Severity:    SeverityLow,
+			Issues:      []string{deprecated.issue},
+		})
+	}
+	return findings
+}
@@ -0,0 +1,129 @@
+package internal
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// Each header disclosing the server software costs disclosurePenalty
+// points, up to maxDisclosurePenalty in total
+const (
+	disclosurePenalty    = 2
+	maxDisclosurePenalty = 5
+)
+
+// versionPattern matches a version number such as 2.4.29 or 7.2
+var versionPattern = regexp.MustCompile(`\d+\.\d+`)
+
+// softwareHeaders reveal the server software. Server is only flagged when
+// it includes a version, the others always.
+var softwareHeaders = []struct {
+	name          string
+	description   string
+	versionedOnly bool
+}{
+	{"Server", "Reveals the web server software and version.", true},
+	{"X-Powered-By", "Reveals the application framework or language runtime.", false},
+	{"X-AspNet-Version", "Reveals the ASP.NET version.", false},
+	{"X-AspNetMvc-Version", "Reveals the ASP.NET MVC version.", false},
+}
+
+// detectDisclosures flags response headers that leak details about the
+// server-side implementation
+func detectDisclosures(header http.Header) []SecurityHeader {
+	disclosures := make([]SecurityHeader, 0)
+
+	disclosures = append(disclosures, detectSoftware(header)...)
+	if finding, ok := detectServerTiming(header); ok {
+		disclosures = append(disclosures, finding)
+	}
+
+	return disclosures
+}
+
+// detectSoftware reports the headers naming the server software, each with
+// a small penalty
+func detectSoftware(header http.Header) []SecurityHeader {
+	findings := make([]SecurityHeader, 0)
+	for _, software := range softwareHeaders {
+		value := strings.TrimSpace(header.Get(software.name))
+		if value == "" || software.versionedOnly && !versionPattern.MatchString(value) {
+			continue
+		}
+
+		findings = append(findings, SecurityHeader{
+			Name:        software.name,
+			Present:     true,
+			Description: software.description,
+			Value:       value,
+			Severity:    SeverityLow,
+			Penalty:     disclosurePenalty,
+			Issues:      []string{"exposes the server software in its value; remove the header or strip the version"},
+		})
+	}
+	return findings
+}
+
+// disclosureDeduction is the score penalty for the disclosures, capped so
+// a single verbose server cannot dominate the grade
+func disclosureDeduction(disclosures []SecurityHeader) int {
+	total := 0
+	for _, disclosure := range disclosures {
+		total += disclosure.Penalty
+	}
+	return min(total, maxDisclosurePenalty)
+}
+
+// detectServerTiming reports the backend components named in Server-Timing
+func detectServerTiming(header http.Header) (SecurityHeader, bool) {
+	values := header.Values("Server-Timing")
+	if len(values) == 0 {
+		return SecurityHeader{}, false
+	}
+
+	value := strings.Join(values, ", ")
+	names := serverTimingNames(value)
+	if len(names) == 0 {
+		return SecurityHeader{}, false
+	}
+
+	issues := make([]string, 0, len(names))
+	for _, name := range names {
+		issues = append(issues, fmt.Sprintf("exposes server-side component %q", name))
+	}
+
+	return SecurityHeader{
+		Name:        "Server-Timing",
+		Present:     true,
+		Description: "Reveals the names and timings of backend components to every client.",
+		Value:       value,
+		Severity:    config.ServerTimingSeverity,
+		Issues:      issues,
+	}, true
+}
+
+// serverTimingNames extracts metric names and descriptions from a
+// Server-Timing value such as `db;dur=53, cache;desc="Redis";dur=2`
+func serverTimingNames(value string) []string {
+	names := make([]string, 0)
+	for _, metric := range strings.Split(value, ",") {
+		params := strings.Split(metric, ";")
+		name := strings.TrimSpace(params[0])
+		if name == "" {
+			continue
+		}
+
+		for _, param := range params[1:] {
+			key, val, found := strings.Cut(strings.TrimSpace(param), "=")
+			if found && strings.EqualFold(strings.TrimSpace(key), "desc") {
+				if desc := strings.Trim(strings.TrimSpace(val), `"`); desc != "" {
+					name += " (" + desc + ")"
+				}
+			}
+		}
+		names = append(names, name)
+	}
+	return names
+}
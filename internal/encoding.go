@@ -0,0 +1,60 @@
+package internal
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+)
+
+// acceptEncoding lists the content codings requested, as a browser would,
+// so the analyzed response carries the headers browsers are served
+const acceptEncoding = "gzip, deflate, br"
+
+// requestCompression asks for a compressed response unless the caller set
+// Accept-Encoding itself. The transport leaves the response as sent, so
+// Content-Encoding and Content-Length stay visible to the analysis and
+// readBody decodes the body.
+func requestCompression(req *http.Request) {
+	if req.Header.Get("Accept-Encoding") == "" {
+		req.Header.Set("Accept-Encoding", acceptEncoding)
+	}
+}
+
+// decodeBody decompresses a gzip, deflate or brotli body, keeping at most
+// maxPageBody of the decoded bytes. A body with any other coding, or one
+// that is not valid for its coding, is returned unchanged.
+func decodeBody(body []byte, encoding string) []byte {
+	var reader io.Reader
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "gzip", "x-gzip":
+		gz, err := gzip.NewReader(bytes.NewReader(body))
+		if err != nil {
+			return body
+		}
+		reader = gz
+	case "deflate":
+		// deflate is meant to be zlib-wrapped, but some servers send it raw
+		if zr, err := zlib.NewReader(bytes.NewReader(body)); err == nil {
+			reader = zr
+		} else {
+			reader = flate.NewReader(bytes.NewReader(body))
+		}
+	case "br":
+		reader = brotli.NewReader(bytes.NewReader(body))
+	default:
+		return body
+	}
+
+	// A body truncated at maxPageBody still decodes up to the cut
+	decoded, err := io.ReadAll(io.LimitReader(reader, maxPageBody))
+	if err != nil && len(decoded) == 0 {
+		return body
+	}
+	return decoded
+}
@@ -0,0 +1,108 @@
+package internal
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+)
+
+// compress encodes body with the writer newWriter wraps around a buffer
+func compress(t *testing.T, body []byte, newWriter func(io.Writer) io.WriteCloser) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w := newWriter(&buf)
+	if _, err := w.Write(body); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestDecodeBody(t *testing.T) {
+	body := []byte(strings.Repeat("<script src=\"/app.js\"></script>", 100))
+
+	tests := []struct {
+		name      string
+		encoding  string
+		newWriter func(io.Writer) io.WriteCloser
+	}{
+		{"gzip", "gzip", func(w io.Writer) io.WriteCloser { return gzip.NewWriter(w) }},
+		{"zlib deflate", "deflate", func(w io.Writer) io.WriteCloser { return zlib.NewWriter(w) }},
+		{"raw deflate", "deflate", func(w io.Writer) io.WriteCloser {
+			fw, _ := flate.NewWriter(w, flate.DefaultCompression)
+			return fw
+		}},
+		{"brotli", "br", func(w io.Writer) io.WriteCloser { return brotli.NewWriter(w) }},
+		{"identity", "", func(w io.Writer) io.WriteCloser { return nopWriteCloser{w} }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			encoded := compress(t, body, tt.newWriter)
+			if got := decodeBody(encoded, tt.encoding); !bytes.Equal(got, body) {
+				t.Errorf("decodeBody with %q returned %d bytes, want the original %d", tt.encoding, len(got), len(body))
+			}
+		})
+	}
+}
+
+func TestDecodeBodyInvalid(t *testing.T) {
+	body := []byte("not compressed")
+	if got := decodeBody(body, "gzip"); !bytes.Equal(got, body) {
+		t.Errorf("decodeBody of an invalid gzip body = %q, want it unchanged", got)
+	}
+}
+
+type nopWriteCloser struct{ io.Writer }
+
+func (nopWriteCloser) Close() error { return nil }
+
+// TestAnalysesReuseConnections runs many analyses in a row against a
+// kept-alive server sending compressed bodies and checks that connections
+// are reused rather than leaked, one per analysis
+func TestAnalysesReuseConnections(t *testing.T) {
+	saved := config.AllowedNetworks
+	_, loopback, _ := net.ParseCIDR("127.0.0.0/8")
+	config.AllowedNetworks = []*net.IPNet{loopback}
+	t.Cleanup(func() { config.AllowedNetworks = saved })
+
+	page := compress(t, []byte(strings.Repeat("<p>hello</p>", 1000)), func(w io.Writer) io.WriteCloser { return gzip.NewWriter(w) })
+
+	var opened atomic.Int32
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Encoding", "gzip")
+		w.Header().Set("X-Frame-Options", "DENY")
+		w.Write(page)
+	}))
+	server.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			opened.Add(1)
+		}
+	}
+	server.Start()
+	defer server.Close()
+
+	const analyses = 30
+	for i := 0; i < analyses; i++ {
+		if _, err := analyze(context.Background(), server.URL, Options{}); err != nil {
+			t.Fatalf("analysis %d: %v", i, err)
+		}
+	}
+
+	if n := opened.Load(); n > 2 {
+		t.Errorf("%d analyses opened %d connections, want them reused", analyses, n)
+	}
+}
@@ -0,0 +1,96 @@
+package internal
+
+import (
+	"context"
+	"net/http"
+)
+
+// Analyzer analyzes targets with its own header definitions, transport and
+// scoring profile. It is safe for concurrent use. Create one with New.
+type Analyzer struct {
+	// headers are the checked header definitions; nil uses the active
+	// package definitions, including configured weights
+	headers []SecurityHeader
+
+	// transport fetches targets, and verifying is its counterpart that
+	// rejects untrusted certificates
+	transport http.RoundTripper
+	verifying http.RoundTripper
+
+	// profile scores results unless the options name another profile
+	profile ScoringProfile
+}
+
+// AnalyzerOption configures an Analyzer
+type AnalyzerOption func(*Analyzer)
+
+// WithHeaders replaces the checked header definitions
+func WithHeaders(headers []SecurityHeader) AnalyzerOption {
+	return func(a *Analyzer) {
+		a.headers = headers
+	}
+}
+
+// WithTransport fetches targets through transport, e.g. one dialing a test
+// server. An *http.Transport is cloned with certificate verification for
+// analyses requiring a valid certificate; any other transport is used as
+// is for those too.
+func WithTransport(transport http.RoundTripper) AnalyzerOption {
+	return func(a *Analyzer) {
+		a.transport, a.verifying = transport, transport
+		if base, ok := transport.(*http.Transport); ok {
+			a.verifying = verifyingClone(base)
+		}
+	}
+}
+
+// WithProfile scores results with profile unless the options name another
+func WithProfile(profile ScoringProfile) AnalyzerOption {
+	return func(a *Analyzer) {
+		a.profile = profile
+	}
+}
+
+// New creates an Analyzer. Without options it checks the active header
+// definitions over the shared transports with the default profile.
+func New(opts ...AnalyzerOption) *Analyzer {
+	a := &Analyzer{transport: sharedTransport, verifying: verifyingTransport}
+	for _, opt := range opts {
+		opt(a)
+	}
+	return a
+}
+
+// defaultAnalyzer backs AnalyzeURL and the other package-level functions
+var defaultAnalyzer = New()
+
+// Analyze fetches url and analyzes its response headers, abandoning the
+// analysis once ctx is done
+func (a *Analyzer) Analyze(ctx context.Context, url string) (*AnalysisResult, error) {
+	return a.AnalyzeWithOptions(ctx, url, Options{})
+}
+
+// AnalyzeWithOptions is Analyze with per-analysis options, and counts the
+// outcome in the metrics
+func (a *Analyzer) AnalyzeWithOptions(ctx context.Context, url string, opts Options) (*AnalysisResult, error) {
+	result, err := a.run(ctx, url, opts)
+	metrics.observe(result, err)
+	return result, err
+}
+
+// definitions returns the checked header definitions
+func (a *Analyzer) definitions() []SecurityHeader {
+	if a.headers == nil {
+		return securityHeaders
+	}
+	return a.headers
+}
+
+// verifyingClone returns a copy of transport that verifies certificates
+func verifyingClone(transport *http.Transport) *http.Transport {
+	clone := transport.Clone()
+	if clone.TLSClientConfig != nil {
+		clone.TLSClientConfig.InsecureSkipVerify = false
+	}
+	return clone
+}
@@ -0,0 +1,73 @@
+package internal
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net"
+	"strings"
+	"syscall"
+)
+
+// FetchErrorKind classifies why fetching the target failed
+type FetchErrorKind string
+
+// Kinds of fetch failures
+const (
+	FetchDNS               FetchErrorKind = "dns"
+	FetchConnectionRefused FetchErrorKind = "connection_refused"
+	FetchTimeout           FetchErrorKind = "timeout"
+	FetchTLS               FetchErrorKind = "tls"
+	FetchNetwork           FetchErrorKind = "network"
+)
+
+// FetchError is returned when the target could not be fetched, telling a
+// wrong URL apart from a server that is down or has a broken TLS setup
+type FetchError struct {
+	Kind FetchErrorKind
+	URL  string
+	Err  error
+}
+
+func (e *FetchError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *FetchError) Unwrap() error {
+	return e.Err
+}
+
+// fetchError wraps a failed fetch of url in a CertificateError or a
+// FetchError. Queue timeouts, blocked addresses and cancellations are
+// returned unchanged.
+func fetchError(url string, err error) error {
+	if errors.Is(err, ErrFetchQueueTimeout) || errors.Is(err, ErrBlockedAddress) || errors.Is(err, context.Canceled) {
+		return err
+	}
+	if certErr := certificateError(url, err); certErr != err {
+		return certErr
+	}
+	return &FetchError{Kind: fetchErrorKind(err), URL: url, Err: err}
+}
+
+// fetchErrorKind classifies the error returned by an HTTP client
+func fetchErrorKind(err error) FetchErrorKind {
+	var dnsErr *net.DNSError
+	var netErr net.Error
+	var recordErr tls.RecordHeaderError
+	var alertErr tls.AlertError
+	switch {
+	case errors.As(err, &dnsErr):
+		return FetchDNS
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return FetchConnectionRefused
+	case errors.Is(err, context.DeadlineExceeded),
+		errors.As(err, &netErr) && netErr.Timeout():
+		return FetchTimeout
+	case errors.As(err, &recordErr), errors.As(err, &alertErr),
+		strings.Contains(err.Error(), "tls: "):
+		return FetchTLS
+	default:
+		return FetchNetwork
+	}
+}
@@ -0,0 +1,93 @@
+package internal
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+)
+
+// Output formats of a formatted result
+const (
+	FormatJSON     = "json"
+	FormatText     = "text"
+	FormatMarkdown = "markdown"
+)
+
+// ErrUnknownFormat is returned for an output format Format does not support
+var ErrUnknownFormat = errors.New("format must be one of json, text, markdown")
+
+// ValidFormat reports whether format is supported by Format
+func ValidFormat(format string) bool {
+	switch format {
+	case FormatJSON, FormatText, FormatMarkdown:
+		return true
+	}
+	return false
+}
+
+// Format renders a result as indented JSON, a plain-text table or a
+// Markdown table suitable for pull request descriptions
+func Format(result *AnalysisResult, format string) (string, error) {
+	switch format {
+	case FormatJSON:
+		data, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			return "", err
+		}
+		return string(data) + "\n", nil
+	case FormatText:
+		return formatText(result)
+	case FormatMarkdown:
+		return formatMarkdown(result), nil
+	default:
+		return "", ErrUnknownFormat
+	}
+}
+
+// headerStatus describes whether a summary entry was found
+func headerStatus(header SecurityHeader) string {
+	switch {
+	case header.Empty:
+		return "empty"
+	case header.Present:
+		return "present"
+	default:
+		return "missing"
+	}
+}
+
+// formatText renders the result as an aligned plain-text table
+func formatText(result *AnalysisResult) (string, error) {
+	var b strings.Builder
+	if result.URL != "" {
+		fmt.Fprintf(&b, "URL:   %s\n", result.URL)
+	}
+	fmt.Fprintf(&b, "Score: %d\nGrade: %s\n\n", result.Score, result.Grade)
+
+	w := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "HEADER\tSTATUS\tAWARDED\tWEIGHT")
+	for _, header := range result.Summary {
+		fmt.Fprintf(w, "%s\t%s\t%d\t%d\n", header.Name, headerStatus(header), header.Awarded, header.Weight)
+	}
+	if err := w.Flush(); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// formatMarkdown renders the result as a Markdown table
+func formatMarkdown(result *AnalysisResult) string {
+	var b strings.Builder
+	if result.URL != "" {
+		fmt.Fprintf(&b, "**%s** — ", result.URL)
+	}
+	fmt.Fprintf(&b, "score %d, grade %s\n\n", result.Score, result.Grade)
+	b.WriteString("| Header | Status | Awarded | Weight |\n")
+	b.WriteString("| --- | --- | ---: | ---: |\n")
+	for _, header := range result.Summary {
+		fmt.Fprintf(&b, "| `%s` | %s | %d | %d |\n", header.Name, headerStatus(header), header.Awarded, header.Weight)
+	}
+	return b.String()
+}
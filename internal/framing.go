@@ -0,0 +1,33 @@
+package internal
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strings"
+)
+
+// checkXFrameOptions awards full credit for DENY and a configurable share
+// of the weight for SAMEORIGIN, which still allows same-origin framing.
+// ALLOW-FROM and any other value are ignored by browsers and earn nothing.
+func checkXFrameOptions(item *SecurityHeader, header http.Header) {
+	value := strings.ToUpper(strings.TrimSpace(header.Get("X-Frame-Options")))
+	switch {
+	case value == "DENY":
+	case value == "SAMEORIGIN":
+		item.Awarded = int(math.Round(float64(item.Weight) * config.XFrameSameOriginCredit))
+		if item.Awarded < item.Weight {
+			item.Issues = append(item.Issues, fmt.Sprintf("SAMEORIGIN still allows framing by same-origin pages; DENY earns full credit (%d of %d awarded)", item.Awarded, item.Weight))
+		}
+	case strings.HasPrefix(value, "ALLOW-FROM"):
+		item.Awarded = 0
+		item.Issues = append(item.Issues, "ALLOW-FROM is deprecated and ignored by modern browsers; use the Content-Security-Policy frame-ancestors directive instead")
+	default:
+		item.Awarded = 0
+		item.Issues = append(item.Issues, "invalid value provides no protection; use DENY or SAMEORIGIN")
+	}
+
+	if _, ok := parseCSP(header.Get("Content-Security-Policy"))["frame-ancestors"]; ok {
+		item.Issues = append(item.Issues, "Content-Security-Policy frame-ancestors is also set and takes precedence, making X-Frame-Options redundant in modern browsers")
+	}
+}
@@ -0,0 +1,35 @@
+package internal
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Grades a result is capped at, whatever its score, when it is served
+// without HTTPS or misses a critical header
+const (
+	httpsCapGrade    = "D"
+	criticalCapGrade = "C"
+)
+
+// capGrade limits the grade earned by the score when the site is not
+// served over HTTPS or misses a critical header, returning the capped
+// grade and the reason, or the grade unchanged and no reason. Grades sort
+// alphabetically from best to worst.
+func capGrade(grade string, summary []SecurityHeader, https bool) (string, string) {
+	if !https && grade < httpsCapGrade {
+		return httpsCapGrade, "site is not served over HTTPS"
+	}
+
+	var missing []string
+	for _, header := range summary {
+		if header.tier() == Critical && (!header.Present || header.Empty) {
+			missing = append(missing, header.Name)
+		}
+	}
+	if len(missing) > 0 && grade < criticalCapGrade {
+		return criticalCapGrade, fmt.Sprintf("missing critical headers: %s", strings.Join(missing, ", "))
+	}
+
+	return grade, ""
+}
@@ -0,0 +1,19 @@
+package internal
+
+// GradeLabel maps every score of at least MinScore to Label, for teams
+// rating results in their own vocabulary (1-5, pass/warn/fail, ...)
+type GradeLabel struct {
+	MinScore int
+	Label    string
+}
+
+// gradeLabel returns the configured label for score: the label of the
+// highest MinScore the score reaches, or "" when none applies
+func gradeLabel(score int) string {
+	for _, label := range config.GradeLabels {
+		if score >= label.MinScore {
+			return label.Label
+		}
+	}
+	return ""
+}
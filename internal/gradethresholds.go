@@ -0,0 +1,46 @@
+package internal
+
+import "fmt"
+
+// GradeThresholds are the minimum scores of grades A to D; lower scores are
+// graded F
+type GradeThresholds struct {
+	A int `json:"A"`
+	B int `json:"B"`
+	C int `json:"C"`
+	D int `json:"D"`
+}
+
+// DefaultGradeThresholds are the grade cutoffs used unless configured
+// otherwise
+var DefaultGradeThresholds = GradeThresholds{A: 80, B: 65, C: 45, D: 25}
+
+// validate checks that the thresholds lie within 0-100 and decrease
+// strictly from A to D
+func (t GradeThresholds) validate() error {
+	if t.A > 100 || t.D < 0 {
+		return fmt.Errorf("grade thresholds must be between 0 and 100, got A=%d, D=%d", t.A, t.D)
+	}
+	if !(t.A > t.B && t.B > t.C && t.C > t.D) {
+		return fmt.Errorf("grade thresholds must decrease from A to D, got A=%d, B=%d, C=%d, D=%d", t.A, t.B, t.C, t.D)
+	}
+	return nil
+}
+
+// gradeFloor is the minimum score of a grade
+type gradeFloor struct {
+	grade string
+	score int
+}
+
+// gradeFloors lists the minimum score of each grade under the active
+// thresholds, best grade first
+func gradeFloors() []gradeFloor {
+	t := config.GradeThresholds
+	return []gradeFloor{
+		{"A", t.A},
+		{"B", t.B},
+		{"C", t.C},
+		{"D", t.D},
+	}
+}
@@ -0,0 +1,135 @@
+package internal
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// grafanaFindingsTarget is the table of the latest findings per URL
+	grafanaFindingsTarget = "findings"
+	// grafanaScorePrefix prefixes the per-URL score time series targets
+	grafanaScorePrefix = "score:"
+)
+
+// GrafanaQuery is the body Grafana's SimpleJSON data source posts to /query
+type GrafanaQuery struct {
+	Range   GrafanaRange    `json:"range"`
+	Targets []GrafanaTarget `json:"targets"`
+}
+
+// GrafanaRange is the dashboard time range of a query
+type GrafanaRange struct {
+	From time.Time `json:"from"`
+	To   time.Time `json:"to"`
+}
+
+// GrafanaTarget is a single queried metric
+type GrafanaTarget struct {
+	Target string `json:"target"`
+	Type   string `json:"type"`
+}
+
+// GrafanaTimeSeries is a series of [value, unix milliseconds] points
+type GrafanaTimeSeries struct {
+	Target     string       `json:"target"`
+	Datapoints [][2]float64 `json:"datapoints"`
+}
+
+// GrafanaColumn describes a table column
+type GrafanaColumn struct {
+	Text string `json:"text"`
+	Type string `json:"type"`
+}
+
+// GrafanaTable is a table response
+type GrafanaTable struct {
+	Type    string          `json:"type"`
+	Columns []GrafanaColumn `json:"columns"`
+	Rows    [][]any         `json:"rows"`
+}
+
+// GrafanaSearch lists the queryable targets: the findings table and a score
+// series per URL in the history
+func (h *History) GrafanaSearch() []string {
+	latest := latestPerURL(h.Entries(time.Time{}, time.Now().Add(time.Minute)))
+
+	urls := make([]string, 0, len(latest))
+	for url := range latest {
+		urls = append(urls, url)
+	}
+	sort.Strings(urls)
+
+	targets := []string{grafanaFindingsTarget}
+	for _, url := range urls {
+		targets = append(targets, grafanaScorePrefix+url)
+	}
+	return targets
+}
+
+// GrafanaQuery answers each target of q from the history within q's range.
+// Unknown targets are skipped.
+func (h *History) GrafanaQuery(q GrafanaQuery) []any {
+	entries := h.Entries(q.Range.From, q.Range.To)
+
+	responses := make([]any, 0, len(q.Targets))
+	for _, target := range q.Targets {
+		switch {
+		case target.Target == grafanaFindingsTarget:
+			responses = append(responses, findingsTable(entries))
+		case strings.HasPrefix(target.Target, grafanaScorePrefix):
+			responses = append(responses, scoreSeries(target.Target, entries))
+		}
+	}
+	return responses
+}
+
+// scoreSeries returns the scores recorded for the URL named by target
+func scoreSeries(target string, entries []HistoryEntry) GrafanaTimeSeries {
+	url := strings.TrimPrefix(target, grafanaScorePrefix)
+	series := GrafanaTimeSeries{Target: target, Datapoints: make([][2]float64, 0)}
+	for _, entry := range entries {
+		if entry.URL == url {
+			series.Datapoints = append(series.Datapoints, [2]float64{float64(entry.Score), float64(entry.RecordedAt.UnixMilli())})
+		}
+	}
+	return series
+}
+
+// findingsTable lists the latest analysis of every URL, worst score first
+func findingsTable(entries []HistoryEntry) GrafanaTable {
+	latest := latestPerURL(entries)
+	sorted := make([]HistoryEntry, 0, len(latest))
+	for _, entry := range latest {
+		sorted = append(sorted, entry)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Score != sorted[j].Score {
+			return sorted[i].Score < sorted[j].Score
+		}
+		return sorted[i].URL < sorted[j].URL
+	})
+
+	table := GrafanaTable{
+		Type: "table",
+		Columns: []GrafanaColumn{
+			{Text: "Time", Type: "time"},
+			{Text: "URL", Type: "string"},
+			{Text: "Grade", Type: "string"},
+			{Text: "Score", Type: "number"},
+			{Text: "Missing", Type: "string"},
+		},
+		Rows: make([][]any, 0, len(sorted)),
+	}
+	for _, entry := range sorted {
+		table.Rows = append(table.Rows, []any{
+			entry.RecordedAt.UnixMilli(),
+			entry.URL,
+			entry.Grade,
+			entry.Score,
+			strings.Join(entry.Missing, ", "),
+		})
+	}
+	return table
+}
@@ -0,0 +1,126 @@
+package internal
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// maxHistoryEntries bounds how many entries are kept in memory
+const maxHistoryEntries = 100000
+
+// HistoryEntry is a single recorded analysis of a URL
+type HistoryEntry struct {
+	URL        string    `json:"url"`
+	Score      int       `json:"score"`
+	Grade      string    `json:"grade"`
+	Missing    []string  `json:"missing,omitempty"`
+	RecordedAt time.Time `json:"recordedAt"`
+}
+
+// History is a concurrency-safe, append-only log of analyses. When backed
+// by a file, entries are persisted as JSON lines and reloaded on open.
+type History struct {
+	mu      sync.RWMutex
+	entries []HistoryEntry
+	file    *os.File
+}
+
+// OpenHistory creates a history, loading and appending to the JSON lines
+// file at path. An empty path keeps the history in memory only.
+func OpenHistory(path string) (*History, error) {
+	h := &History{}
+	if path == "" {
+		return h, nil
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		var entry HistoryEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			file.Close()
+			return nil, fmt.Errorf("history %s line %d: %w", path, line, err)
+		}
+		h.append(entry)
+	}
+	if err := scanner.Err(); err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	h.file = file
+	return h, nil
+}
+
+// Record appends result to the history. Recording into a nil history is a no-op.
+func (h *History) Record(result *AnalysisResult) error {
+	if h == nil || result == nil {
+		return nil
+	}
+
+	entry := HistoryEntry{
+		URL:        result.URL,
+		Score:      result.Score,
+		Grade:      result.Grade,
+		RecordedAt: time.Now().UTC(),
+	}
+	for _, header := range result.Summary {
+		// Informational headers weighted 0 never cost points
+		if !header.Present && header.Weight > 0 {
+			entry.Missing = append(entry.Missing, header.Name)
+		}
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.append(entry)
+	if h.file == nil {
+		return nil
+	}
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	_, err = h.file.Write(append(line, '\n'))
+	return err
+}
+
+// append adds an entry, dropping the oldest ones beyond the in-memory bound
+func (h *History) append(entry HistoryEntry) {
+	h.entries = append(h.entries, entry)
+	if len(h.entries) > maxHistoryEntries {
+		h.entries = h.entries[len(h.entries)-maxHistoryEntries:]
+	}
+}
+
+// Entries returns the entries recorded in [from, to), oldest first
+func (h *History) Entries(from, to time.Time) []HistoryEntry {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	entries := make([]HistoryEntry, 0)
+	for _, entry := range h.entries {
+		if !entry.RecordedAt.Before(from) && entry.RecordedAt.Before(to) {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+// Close releases the backing file, if any
+func (h *History) Close() error {
+	if h == nil || h.file == nil {
+		return nil
+	}
+	return h.file.Close()
+}
@@ -0,0 +1,35 @@
+package internal
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestHistoryRecordSkipsInformationalHeaders(t *testing.T) {
+	history, err := OpenHistory("")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result := &AnalysisResult{
+		URL:   "https://example.com",
+		Grade: "C",
+		Summary: []SecurityHeader{
+			{Name: "X-Frame-Options", Weight: 15},
+			{Name: "Set-Login", Weight: 0},
+			{Name: "Referrer-Policy", Weight: 15, Present: true},
+		},
+	}
+	if err := history.Record(result); err != nil {
+		t.Fatal(err)
+	}
+
+	entries := history.Entries(time.Time{}, time.Now().Add(time.Minute))
+	if len(entries) != 1 {
+		t.Fatalf("got %d entries, want 1", len(entries))
+	}
+	if want := []string{"X-Frame-Options"}; !slices.Equal(entries[0].Missing, want) {
+		t.Errorf("missing = %v, want %v", entries[0].Missing, want)
+	}
+}
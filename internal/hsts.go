@@ -0,0 +1,161 @@
+package internal
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// hstsMinMaxAge is the max-age, six months in seconds, below which
+// Strict-Transport-Security earns only partial credit
+const hstsMinMaxAge = 15552000
+
+// hstsPreloadMaxAge is the minimum max-age accepted by the HSTS preload list
+const hstsPreloadMaxAge = 31536000
+
+// hstsPolicy holds the directives of a Strict-Transport-Security value
+type hstsPolicy struct {
+	MaxAge            int64
+	HasMaxAge         bool
+	IncludeSubDomains bool
+	Preload           bool
+}
+
+// parseHSTS extracts the max-age, includeSubDomains and preload directives
+// from a Strict-Transport-Security value. Of repeated max-age directives the
+// lowest is assumed.
+func parseHSTS(value string) hstsPolicy {
+	var policy hstsPolicy
+	if maxAges := hstsMaxAges(value); len(maxAges) > 0 {
+		policy.MaxAge = slices.Min(maxAges)
+		policy.HasMaxAge = true
+	}
+	for _, directive := range strings.Split(value, ";") {
+		switch strings.ToLower(strings.TrimSpace(directive)) {
+		case "includesubdomains":
+			policy.IncludeSubDomains = true
+		case "preload":
+			policy.Preload = true
+		}
+	}
+	return policy
+}
+
+// weakestHSTS combines the policies of several Strict-Transport-Security
+// values into the weakest of them: a max-age only if every value has one,
+// the lowest max-age and includeSubDomains only if every value carries it.
+// preload is kept if any value asks for it, so its requirements are checked.
+func weakestHSTS(values []string) hstsPolicy {
+	var weakest hstsPolicy
+	for i, value := range values {
+		policy := parseHSTS(value)
+		if i == 0 {
+			weakest = policy
+			continue
+		}
+		if !policy.HasMaxAge {
+			weakest.HasMaxAge = false
+		}
+		if policy.HasMaxAge && policy.MaxAge < weakest.MaxAge {
+			weakest.MaxAge = policy.MaxAge
+		}
+		weakest.IncludeSubDomains = weakest.IncludeSubDomains && policy.IncludeSubDomains
+		weakest.Preload = weakest.Preload || policy.Preload
+	}
+	return weakest
+}
+
+// checkHSTS runs the Strict-Transport-Security value checks
+func checkHSTS(item *SecurityHeader, header http.Header) {
+	checkHSTSConflicts(item, header)
+	checkHSTSDirectives(item, header)
+}
+
+// checkHSTSDirectives validates the directives of the weakest of the
+// Strict-Transport-Security headers. A missing or zero max-age earns no
+// credit; a max-age under six months or a missing includeSubDomains earns
+// half the weight.
+func checkHSTSDirectives(item *SecurityHeader, header http.Header) {
+	policy := weakestHSTS(header.Values("Strict-Transport-Security"))
+	switch {
+	case !policy.HasMaxAge:
+		item.Awarded = 0
+		item.Issues = append(item.Issues, "missing max-age; browsers ignore the header")
+		return
+	case policy.MaxAge <= 0:
+		item.Awarded = 0
+		item.Issues = append(item.Issues, "max-age of 0 removes the HSTS policy")
+		return
+	}
+
+	weak := false
+	if policy.MaxAge < hstsMinMaxAge {
+		weak = true
+		item.Issues = append(item.Issues, fmt.Sprintf("max-age too low (%d, at least %d recommended)", policy.MaxAge, hstsMinMaxAge))
+	}
+	if !policy.IncludeSubDomains {
+		weak = true
+		item.Issues = append(item.Issues, "missing includeSubDomains")
+	}
+	if policy.Preload && (!policy.IncludeSubDomains || policy.MaxAge < hstsPreloadMaxAge) {
+		item.Issues = append(item.Issues, fmt.Sprintf("preload requires includeSubDomains and a max-age of at least %d", hstsPreloadMaxAge))
+	}
+	if weak && item.Awarded > item.Weight/2 {
+		item.Awarded = item.Weight / 2
+	}
+}
+
+// hstsMaxAges extracts every max-age directive from a Strict-Transport-Security value
+func hstsMaxAges(value string) []int64 {
+	var maxAges []int64
+	for _, directive := range strings.Split(value, ";") {
+		name, val, found := strings.Cut(strings.TrimSpace(directive), "=")
+		if !found || !strings.EqualFold(strings.TrimSpace(name), "max-age") {
+			continue
+		}
+		maxAge, err := strconv.ParseInt(strings.Trim(strings.TrimSpace(val), `"`), 10, 64)
+		if err != nil {
+			continue
+		}
+		maxAges = append(maxAges, maxAge)
+	}
+	return maxAges
+}
+
+// checkHSTSConflicts flags responses carrying several Strict-Transport-Security
+// headers or repeated max-age directives. Browsers disagree on which one wins,
+// so the lowest max-age is assumed and a max-age of 0 earns no credit.
+func checkHSTSConflicts(item *SecurityHeader, header http.Header) {
+	values := header.Values("Strict-Transport-Security")
+	if len(values) > 1 {
+		item.Values = values
+		item.Issues = append(item.Issues, fmt.Sprintf("%d Strict-Transport-Security headers were sent", len(values)))
+	}
+
+	var maxAges []int64
+	for _, value := range values {
+		maxAges = append(maxAges, hstsMaxAges(value)...)
+	}
+
+	distinct := make(map[int64]bool)
+	for _, maxAge := range maxAges {
+		distinct[maxAge] = true
+	}
+	if len(distinct) < 2 {
+		return
+	}
+
+	sort.Slice(maxAges, func(i, j int) bool { return maxAges[i] < maxAges[j] })
+	observed := make([]string, len(maxAges))
+	for i, maxAge := range maxAges {
+		observed[i] = strconv.FormatInt(maxAge, 10)
+	}
+	item.Issues = append(item.Issues, fmt.Sprintf("conflicting max-age values (%s); the lowest is assumed", strings.Join(observed, ", ")))
+
+	if maxAges[0] <= 0 {
+		item.Awarded = 0
+	}
+}
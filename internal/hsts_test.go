@@ -0,0 +1,37 @@
+package internal
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestCheckHSTS(t *testing.T) {
+	tests := []struct {
+		name    string
+		values  []string
+		awarded int
+	}{
+		{"strong policy", []string{"max-age=63072000; includeSubDomains"}, 20},
+		{"low max-age", []string{"max-age=300; includeSubDomains"}, 10},
+		{"missing includeSubDomains", []string{"max-age=63072000"}, 10},
+		{"missing max-age", []string{"includeSubDomains"}, 0},
+		{"zero max-age", []string{"max-age=0; includeSubDomains"}, 0},
+		{"repeated max-age in one value", []string{"max-age=63072000; max-age=300; includeSubDomains"}, 10},
+		{"strong then weak header", []string{"max-age=63072000; includeSubDomains", "max-age=300; includeSubDomains"}, 10},
+		{"weak then strong header", []string{"max-age=300; includeSubDomains", "max-age=63072000; includeSubDomains"}, 10},
+		{"one header without includeSubDomains", []string{"max-age=63072000; includeSubDomains", "max-age=63072000"}, 10},
+		{"one header without max-age", []string{"max-age=63072000; includeSubDomains", "includeSubDomains"}, 0},
+		{"one header with zero max-age", []string{"max-age=63072000; includeSubDomains", "max-age=0"}, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := http.Header{"Strict-Transport-Security": tt.values}
+			item := SecurityHeader{Name: "Strict-Transport-Security", Present: true, Weight: 20, Awarded: 20}
+			checkHSTS(&item, header)
+			if item.Awarded != tt.awarded {
+				t.Errorf("awarded %d, want %d (issues: %v)", item.Awarded, tt.awarded, item.Issues)
+			}
+		})
+	}
+}
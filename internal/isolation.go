@@ -0,0 +1,48 @@
+package internal
+
+import (
+	"net/http"
+	"strings"
+)
+
+// coepPolicy returns the policy token of a Cross-Origin-Embedder-Policy
+// value, without parameters such as report-to
+func coepPolicy(header http.Header) string {
+	policy, _, _ := strings.Cut(header.Get("Cross-Origin-Embedder-Policy"), ";")
+	return strings.ToLower(strings.TrimSpace(policy))
+}
+
+// validCOEP reports whether policy makes browsers enforce embedding
+// restrictions
+func validCOEP(policy string) bool {
+	return policy == "require-corp" || policy == "credentialless"
+}
+
+// checkCOEP awards Cross-Origin-Embedder-Policy only for require-corp or
+// credentialless; unsafe-none is the browser default and protects nothing
+func checkCOEP(item *SecurityHeader, header http.Header) {
+	switch policy := coepPolicy(header); {
+	case validCOEP(policy):
+	case policy == "unsafe-none":
+		item.Awarded = 0
+		item.Issues = append(item.Issues, "unsafe-none is the browser default and allows embedding any cross-origin resource; use require-corp or credentialless")
+	default:
+		item.Awarded = 0
+		item.Issues = append(item.Issues, "unknown policy is ignored by browsers; use require-corp or credentialless")
+	}
+}
+
+// checkCOOP notes whether Cross-Origin-Opener-Policy achieves cross-origin
+// isolation, which also takes a Cross-Origin-Embedder-Policy. It does not
+// affect the awarded weight.
+func checkCOOP(item *SecurityHeader, header http.Header) {
+	policy := coepPolicy(header)
+	switch {
+	case policy == "":
+		item.Issues = append(item.Issues, "cross-origin isolation is incomplete without Cross-Origin-Embedder-Policy: require-corp or credentialless")
+	case !validCOEP(policy):
+		item.Issues = append(item.Issues, "cross-origin isolation is incomplete because Cross-Origin-Embedder-Policy is not require-corp or credentialless")
+	case strings.EqualFold(strings.TrimSpace(header.Get("Cross-Origin-Opener-Policy")), "same-origin"):
+		item.Notes = append(item.Notes, "with Cross-Origin-Embedder-Policy the page is cross-origin isolated")
+	}
+}
@@ -0,0 +1,121 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+)
+
+// DefaultFetchQueueTimeout is how long an outbound fetch waits for a free
+// slot when the concurrency limit is reached
+const DefaultFetchQueueTimeout = 30 * time.Second
+
+// ErrFetchQueueTimeout is returned when an outbound fetch waited too long
+// for a free slot under the global concurrency limit
+var ErrFetchQueueTimeout = errors.New("too many concurrent outbound requests; timed out waiting for a free slot")
+
+// fetchSlots is the semaphore shared by every outbound fetch of every
+// endpoint. It is nil while concurrency is unlimited.
+var fetchSlots chan struct{}
+
+// newFetchSlots returns a semaphore admitting limit concurrent fetches, or
+// nil for an unlimited one
+func newFetchSlots(limit int) chan struct{} {
+	if limit <= 0 {
+		return nil
+	}
+	return make(chan struct{}, limit)
+}
+
+// acquireFetch waits for a free fetch slot, giving up once ctx is done or
+// the configured queue timeout elapses. The returned func frees the slot.
+func acquireFetch(ctx context.Context) (func(), error) {
+	slots := fetchSlots
+	if slots == nil {
+		return func() {}, nil
+	}
+
+	select {
+	case slots <- struct{}{}:
+		return func() { <-slots }, nil
+	default:
+	}
+
+	timer := time.NewTimer(config.FetchQueueTimeout)
+	defer timer.Stop()
+
+	select {
+	case slots <- struct{}{}:
+		return func() { <-slots }, nil
+	case <-timer.C:
+		return nil, ErrFetchQueueTimeout
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// limitedTransport holds a fetch slot for each round trip. The slot is
+// freed once the response headers arrive, so an analysis reading a page
+// body can still fetch the page's subresources without deadlocking. Each
+// round trip, including reading its body, is bounded by timeout through
+// the request context, so the shared base transport needs no timeout.
+type limitedTransport struct {
+	base    http.RoundTripper
+	timeout time.Duration
+}
+
+func (t *limitedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx, cancel := req.Context(), context.CancelFunc(func() {})
+	if t.timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, t.timeout)
+	}
+
+	release, err := acquireFetch(ctx)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	defer release()
+
+	req = req.WithContext(ctx)
+	if req.Header.Get("User-Agent") == "" {
+		// The header map is shared with the caller's request, which a
+		// RoundTripper must not modify
+		header := req.Header.Clone()
+		if header == nil {
+			header = make(http.Header)
+		}
+		header.Set("User-Agent", config.UserAgent)
+		req.Header = header
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+// cancelOnClose releases the context of a round trip once its body is closed
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelOnClose) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+// CloseIdleConnections closes the kept-alive connections of the transport,
+// which http.Client.CloseIdleConnections relies on
+func (t *limitedTransport) CloseIdleConnections() {
+	if base, ok := t.base.(interface{ CloseIdleConnections() }); ok {
+		base.CloseIdleConnections()
+	}
+}
@@ -0,0 +1,185 @@
+package internal
+
+import (
+	"fmt"
+	"net/http"
+	neturl "net/url"
+	"strings"
+)
+
+// securityRelevantRels are the Link relations that make the browser reach
+// out to other origins before the page asks for them
+var securityRelevantRels = map[string]bool{
+	"preconnect":    true,
+	"dns-prefetch":  true,
+	"preload":       true,
+	"modulepreload": true,
+	"prefetch":      true,
+	"prerender":     true,
+}
+
+// preloadDirectives maps a preload destination to the CSP directive that
+// governs it
+var preloadDirectives = map[string]string{
+	"script": "script-src",
+	"style":  "style-src",
+	"font":   "font-src",
+	"image":  "img-src",
+	"fetch":  "connect-src",
+	"worker": "worker-src",
+}
+
+// LinkHint is a security-relevant resource hint from the Link header
+type LinkHint struct {
+	URL         string   `json:"url"`
+	Rel         string   `json:"rel"`
+	As          string   `json:"as,omitempty"`
+	CrossOrigin bool     `json:"crossOrigin"`
+	Issues      []string `json:"issues,omitempty"`
+}
+
+// linkEntry is a single parsed Link header value
+type linkEntry struct {
+	target string
+	params map[string]string
+}
+
+// parseLink splits a Link header value into its entries, keeping commas
+// inside the URL or quoted parameters intact
+func parseLink(value string) []linkEntry {
+	entries := make([]linkEntry, 0)
+	for {
+		start := strings.IndexByte(value, '<')
+		if start < 0 {
+			return entries
+		}
+		end := strings.IndexByte(value[start:], '>')
+		if end < 0 {
+			return entries
+		}
+		entry := linkEntry{target: value[start+1 : start+end], params: make(map[string]string)}
+		value = value[start+end+1:]
+
+		// parameters run up to the next comma outside quotes
+		quoted, cut := false, len(value)
+		for i, r := range value {
+			if r == '"' {
+				quoted = !quoted
+			} else if r == ',' && !quoted {
+				cut = i
+				break
+			}
+		}
+		for _, param := range strings.Split(value[:cut], ";") {
+			name, val, _ := strings.Cut(param, "=")
+			name = strings.ToLower(strings.TrimSpace(name))
+			if name != "" {
+				entry.params[name] = strings.Trim(strings.TrimSpace(val), `"`)
+			}
+		}
+		entries = append(entries, entry)
+		value = value[cut:]
+	}
+}
+
+// linkHints reports the security-relevant resource hints of the Link
+// header. Cross-origin preloads are checked against the page's CSP.
+func linkHints(pageURL string, header http.Header) []LinkHint {
+	page, err := neturl.Parse(pageURL)
+	if err != nil {
+		return nil
+	}
+	policy := parseCSP(cspValue(header))
+
+	hints := make([]LinkHint, 0)
+	for _, entry := range parseLink(strings.Join(header.Values("Link"), ", ")) {
+		target, err := page.Parse(entry.target)
+		if err != nil {
+			continue
+		}
+
+		for _, rel := range strings.Fields(strings.ToLower(entry.params["rel"])) {
+			if !securityRelevantRels[rel] {
+				continue
+			}
+
+			hint := LinkHint{
+				URL:         target.String(),
+				Rel:         rel,
+				As:          strings.ToLower(entry.params["as"]),
+				CrossOrigin: target.Host != page.Host || target.Scheme != page.Scheme,
+			}
+			if rel == "modulepreload" && hint.As == "" {
+				hint.As = "script"
+			}
+			if hint.CrossOrigin {
+				hint.Issues = append(hint.Issues, fmt.Sprintf("%s reaches out to third-party origin %s", rel, target.Scheme+"://"+target.Host))
+			}
+			if directive, sources, ok := policy.sourcesFor(hint.As); ok && !cspAllows(sources, target, page) {
+				hint.Issues = append(hint.Issues, fmt.Sprintf("%s is not allowed by the CSP %s, so the preloaded %s will be blocked", hint.URL, directive, hint.As))
+			}
+			hints = append(hints, hint)
+		}
+	}
+	return hints
+}
+
+// sourcesFor returns the directive governing a preload destination and its
+// source list, falling back to default-src. It reports false when the
+// policy does not restrict the destination.
+func (p cspPolicy) sourcesFor(destination string) (string, []string, bool) {
+	directive, ok := preloadDirectives[destination]
+	if !ok {
+		return "", nil, false
+	}
+	if sources, ok := p[directive]; ok {
+		return directive, sources, true
+	}
+	if sources, ok := p["default-src"]; ok {
+		return "default-src", sources, true
+	}
+	return "", nil, false
+}
+
+// cspAllows reports whether a CSP source list admits target, covering the
+// wildcard, scheme, 'self' and host (with optional *. prefix) sources
+func cspAllows(sources []string, target, page *neturl.URL) bool {
+	for _, source := range sources {
+		source = strings.ToLower(source)
+		switch {
+		case source == "*":
+			return true
+		case source == "'self'":
+			if target.Scheme == page.Scheme && target.Host == page.Host {
+				return true
+			}
+		case strings.HasSuffix(source, ":") && !strings.Contains(source, "/"):
+			if target.Scheme+":" == source {
+				return true
+			}
+		case !strings.HasPrefix(source, "'"):
+			if hostSourceMatches(source, target) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// hostSourceMatches matches a CSP host source such as https://cdn.example.com
+// or *.example.com against target, ignoring any path
+func hostSourceMatches(source string, target *neturl.URL) bool {
+	if scheme, rest, ok := strings.Cut(source, "://"); ok {
+		if scheme != target.Scheme {
+			return false
+		}
+		source = rest
+	}
+	host, _, _ := strings.Cut(source, "/")
+	targetHost := strings.ToLower(target.Host)
+
+	if suffix, ok := strings.CutPrefix(host, "*."); ok {
+		return strings.HasSuffix(targetHost, "."+suffix)
+	}
+	return host == targetHost || host == strings.ToLower(target.Hostname())
+}
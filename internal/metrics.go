@@ -0,0 +1,111 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"sync"
+)
+
+// metricsRegistry counts completed and failed analyses. It is safe for
+// concurrent use.
+type metricsRegistry struct {
+	mu       sync.Mutex
+	analyses uint64
+	scoreSum uint64
+	grades   map[string]uint64
+	errors   map[string]uint64
+}
+
+// metrics counts every analysis that fetches a target, whichever endpoint
+// or the scheduler started it
+var metrics = &metricsRegistry{
+	grades: make(map[string]uint64),
+	errors: make(map[string]uint64),
+}
+
+// observe records the outcome of one analysis
+func (m *metricsRegistry) observe(result *AnalysisResult, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err != nil {
+		m.errors[errorKind(err)]++
+		return
+	}
+	m.analyses++
+	m.scoreSum += uint64(result.Score)
+	m.grades[result.Grade]++
+}
+
+// errorKind labels a failed analysis for the error counter
+func errorKind(err error) string {
+	var fetchErr *FetchError
+	var certErr *CertificateError
+	var statusErr *StatusError
+	switch {
+	case errors.As(err, &fetchErr):
+		return string(fetchErr.Kind)
+	case errors.As(err, &certErr):
+		return "certificate"
+	case errors.As(err, &statusErr):
+		return "status"
+	case errors.Is(err, ErrInvalidURL):
+		return "invalid_url"
+	case errors.Is(err, ErrBlockedAddress):
+		return "blocked_address"
+	case errors.Is(err, ErrFetchQueueTimeout):
+		return "queue_timeout"
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return "canceled"
+	default:
+		return "other"
+	}
+}
+
+// WriteMetrics writes the analysis counters in the Prometheus text
+// exposition format
+func WriteMetrics(w io.Writer) error {
+	metrics.mu.Lock()
+	defer metrics.mu.Unlock()
+
+	average := 0.0
+	if metrics.analyses > 0 {
+		average = float64(metrics.scoreSum) / float64(metrics.analyses)
+	}
+
+	_, err := fmt.Fprintf(w, `# HELP header_analyzer_analyses_total Completed analyses.
+# TYPE header_analyzer_analyses_total counter
+header_analyzer_analyses_total %d
+# HELP header_analyzer_score_sum Sum of the scores of completed analyses.
+# TYPE header_analyzer_score_sum counter
+header_analyzer_score_sum %d
+# HELP header_analyzer_score_average Average score of completed analyses.
+# TYPE header_analyzer_score_average gauge
+header_analyzer_score_average %g
+# HELP header_analyzer_grades_total Completed analyses by grade.
+# TYPE header_analyzer_grades_total counter
+%s# HELP header_analyzer_errors_total Failed analyses by error kind.
+# TYPE header_analyzer_errors_total counter
+%s`, metrics.analyses, metrics.scoreSum, average,
+		labeledCounter("header_analyzer_grades_total", "grade", metrics.grades),
+		labeledCounter("header_analyzer_errors_total", "kind", metrics.errors))
+	return err
+}
+
+// labeledCounter formats one sample per label value, sorted by value
+func labeledCounter(name, label string, counts map[string]uint64) string {
+	values := make([]string, 0, len(counts))
+	for value := range counts {
+		values = append(values, value)
+	}
+	slices.Sort(values)
+
+	var samples string
+	for _, value := range values {
+		samples += fmt.Sprintf("%s{%s=%q} %d\n", name, label, value, counts[value])
+	}
+	return samples
+}
@@ -0,0 +1,261 @@
+package internal
+
+import (
+	"net/http"
+	neturl "net/url"
+	"slices"
+	"strings"
+)
+
+// mozillaBaseline is the score every site starts from
+const mozillaBaseline = 100
+
+// mozillaBonusThreshold is the score, before bonuses, a site needs for
+// bonus points to count
+const mozillaBonusThreshold = 90
+
+// mozillaGradeFloors maps Observatory scores to grades, best first
+var mozillaGradeFloors = []struct {
+	grade string
+	score int
+}{
+	{"A+", 100}, {"A", 90}, {"A-", 85},
+	{"B+", 80}, {"B", 70}, {"B-", 65},
+	{"C+", 60}, {"C", 50}, {"C-", 45},
+	{"D+", 40}, {"D", 30}, {"D-", 25},
+}
+
+// mozillaGrade returns the Observatory grade for score
+func mozillaGrade(score int) string {
+	for _, floor := range mozillaGradeFloors {
+		if score >= floor.score {
+			return floor.grade
+		}
+	}
+	return "F"
+}
+
+// mozillaScore mirrors the Mozilla Observatory: every site starts at 100
+// and each test adds or deducts points. Bonuses only count once the score
+// reaches 90 without them, and the score never drops below 0.
+func mozillaScore(in scoringInput) *ScoreBreakdown {
+	tests := []ScoreItem{
+		mozillaCSP(in.header),
+		mozillaCookies(in.result.Cookies, in.result.HTTPS && mozillaHSTSMaxAge(in.header) > 0),
+		mozillaHSTS(in.header, in.result.HTTPS),
+		mozillaRedirection(in.upgrade),
+		mozillaContentTypeOptions(in.header),
+		mozillaFrameOptions(in.header),
+		mozillaReferrerPolicy(in.header),
+	}
+
+	items := []ScoreItem{{Label: "Baseline", Points: mozillaBaseline}}
+	withoutBonuses := mozillaBaseline
+	for _, test := range tests {
+		if test.Points < 0 {
+			withoutBonuses += test.Points
+		}
+	}
+	for _, test := range tests {
+		if test.Points > 0 && withoutBonuses < mozillaBonusThreshold {
+			test.Label += " (bonus withheld below 90)"
+			test.Points = 0
+		}
+		items = append(items, test)
+	}
+	return newScoreBreakdown(items)
+}
+
+// mozillaCSP scores the enforced Content-Security-Policy by its worst
+// construct; a report-only policy does not count
+func mozillaCSP(header http.Header) ScoreItem {
+	value := header.Get("Content-Security-Policy")
+	if value == "" {
+		return ScoreItem{Label: "Content-Security-Policy: not implemented", Points: -25}
+	}
+
+	policy := parseCSP(value)
+	_, scripts, ok := policy.scriptSources()
+	passive := append(append([]string{}, policy.sources("img-src")...), policy.sources("media-src")...)
+	switch {
+	case !ok:
+		return ScoreItem{Label: "Content-Security-Policy: neither script-src nor default-src restricts scripts", Points: -20}
+	case cspHasSource(scripts, "'unsafe-inline'") && !cspHasNonceOrHash(scripts):
+		return ScoreItem{Label: "Content-Security-Policy: implemented with 'unsafe-inline' scripts", Points: -20}
+	case cspInsecureScheme(scripts):
+		return ScoreItem{Label: "Content-Security-Policy: allows scripts over plain HTTP", Points: -20}
+	case cspHasSource(scripts, "'unsafe-eval'"):
+		return ScoreItem{Label: "Content-Security-Policy: implemented with 'unsafe-eval'", Points: -10}
+	case cspInsecureScheme(passive):
+		return ScoreItem{Label: "Content-Security-Policy: allows passive content over plain HTTP", Points: -10}
+	case cspOnlyNone(policy["default-src"]):
+		return ScoreItem{Label: "Content-Security-Policy: implemented with default-src 'none' and no 'unsafe-inline' or 'unsafe-eval'", Points: 10}
+	default:
+		return ScoreItem{Label: "Content-Security-Policy: implemented without 'unsafe-inline' or 'unsafe-eval'", Points: 5}
+	}
+}
+
+// sources returns the source list governing directive, falling back to
+// default-src
+func (p cspPolicy) sources(directive string) []string {
+	if sources, ok := p[directive]; ok {
+		return sources
+	}
+	return p["default-src"]
+}
+
+// cspInsecureScheme reports whether a source list allows plain HTTP
+func cspInsecureScheme(sources []string) bool {
+	for _, source := range sources {
+		lower := strings.ToLower(source)
+		if lower == "http:" || strings.HasPrefix(lower, "http://") {
+			return true
+		}
+	}
+	return false
+}
+
+// mozillaSessionCookie guesses whether a cookie holds a session, as the
+// Observatory does, from its name
+func mozillaSessionCookie(name string) bool {
+	name = strings.ToLower(name)
+	return strings.Contains(name, "sess") || strings.Contains(name, "login")
+}
+
+// mozillaCookies scores the cookies by the worst one; a cookie lacking
+// Secure is less serious when HSTS keeps it off plain HTTP
+func mozillaCookies(cookies []CookieFinding, hsts bool) ScoreItem {
+	worst := ScoreItem{Label: "Cookies: none set", Points: 0}
+	found := false
+	worse := func(item ScoreItem) {
+		if item.Points < worst.Points || !found {
+			worst = item
+		}
+		found = true
+	}
+
+	allSameSite := true
+	for _, cookie := range cookies {
+		if cookie.Cleared {
+			continue
+		}
+		session := mozillaSessionCookie(cookie.Name)
+		switch {
+		case !cookie.Secure && session && !hsts:
+			worse(ScoreItem{Label: "Cookies: session cookie set without the Secure flag", Points: -40})
+		case !cookie.Secure && !hsts:
+			worse(ScoreItem{Label: "Cookies: set without the Secure flag", Points: -20})
+		case session && !cookie.HttpOnly:
+			worse(ScoreItem{Label: "Cookies: session cookie set without the HttpOnly flag", Points: -10})
+		case !cookie.Secure:
+			worse(ScoreItem{Label: "Cookies: set without the Secure flag, but protected by HSTS", Points: -5})
+		default:
+			worse(ScoreItem{Label: "Cookies: all secure and session cookies HttpOnly", Points: 0})
+		}
+		if cookie.SameSite == "" {
+			allSameSite = false
+		}
+	}
+	if found && worst.Points == 0 && allSameSite {
+		return ScoreItem{Label: "Cookies: all secure, session cookies HttpOnly and SameSite set", Points: 5}
+	}
+	return worst
+}
+
+// mozillaHSTSMaxAge returns the lowest max-age of the HSTS header, or -1
+// when it has none
+func mozillaHSTSMaxAge(header http.Header) int64 {
+	maxAges := hstsMaxAges(strings.Join(header.Values("Strict-Transport-Security"), ", "))
+	if len(maxAges) == 0 {
+		return -1
+	}
+	return slices.Min(maxAges)
+}
+
+// mozillaHSTS scores Strict-Transport-Security. Preloading is judged from
+// the preload directive, since the preload list itself is not consulted.
+func mozillaHSTS(header http.Header, https bool) ScoreItem {
+	if !https {
+		return ScoreItem{Label: "Strict-Transport-Security: site is not served over HTTPS", Points: -20}
+	}
+	if header.Get("Strict-Transport-Security") == "" {
+		return ScoreItem{Label: "Strict-Transport-Security: not implemented", Points: -20}
+	}
+
+	maxAge := mozillaHSTSMaxAge(header)
+	policy := parseHSTS(header.Get("Strict-Transport-Security"))
+	switch {
+	case maxAge < 0:
+		return ScoreItem{Label: "Strict-Transport-Security: header cannot be parsed", Points: -20}
+	case maxAge < hstsMinMaxAge:
+		return ScoreItem{Label: "Strict-Transport-Security: max-age is less than six months", Points: -10}
+	case policy.Preload && policy.IncludeSubDomains && maxAge >= hstsPreloadMaxAge:
+		return ScoreItem{Label: "Strict-Transport-Security: eligible for preloading", Points: 5}
+	default:
+		return ScoreItem{Label: "Strict-Transport-Security: max-age is at least six months", Points: 0}
+	}
+}
+
+// mozillaRedirection scores how plain HTTP is redirected to HTTPS
+func mozillaRedirection(upgrade *UpgradeResult) ScoreItem {
+	if upgrade == nil || len(upgrade.Hops) == 0 {
+		return ScoreItem{Label: "Redirection: not needed, plain HTTP is not served", Points: 0}
+	}
+	if !upgrade.Upgraded {
+		return ScoreItem{Label: "Redirection: plain HTTP does not redirect to HTTPS", Points: -20}
+	}
+
+	first := upgrade.Hops[0]
+	start, _ := neturl.Parse(first.URL)
+	if next, err := start.Parse(first.Location); err == nil {
+		if next.Scheme != "https" {
+			return ScoreItem{Label: "Redirection: the initial redirect is not to HTTPS", Points: -10}
+		}
+		if next.Hostname() != start.Hostname() {
+			return ScoreItem{Label: "Redirection: the initial redirect from HTTP leaves the host", Points: -5}
+		}
+	}
+	return ScoreItem{Label: "Redirection: plain HTTP redirects to HTTPS on the same host", Points: 0}
+}
+
+// mozillaContentTypeOptions scores X-Content-Type-Options
+func mozillaContentTypeOptions(header http.Header) ScoreItem {
+	if strings.EqualFold(strings.TrimSpace(header.Get("X-Content-Type-Options")), "nosniff") {
+		return ScoreItem{Label: "X-Content-Type-Options: set to nosniff", Points: 0}
+	}
+	return ScoreItem{Label: "X-Content-Type-Options: not set to nosniff", Points: -5}
+}
+
+// mozillaFrameOptions scores framing protection, preferring the CSP
+// frame-ancestors directive over X-Frame-Options
+func mozillaFrameOptions(header http.Header) ScoreItem {
+	if _, ok := parseCSP(header.Get("Content-Security-Policy"))["frame-ancestors"]; ok {
+		return ScoreItem{Label: "X-Frame-Options: framing restricted by CSP frame-ancestors", Points: 5}
+	}
+	switch strings.ToUpper(strings.TrimSpace(header.Get("X-Frame-Options"))) {
+	case "DENY", "SAMEORIGIN":
+		return ScoreItem{Label: "X-Frame-Options: set to DENY or SAMEORIGIN", Points: 0}
+	}
+	return ScoreItem{Label: "X-Frame-Options: not implemented", Points: -20}
+}
+
+// mozillaReferrerPolicy scores Referrer-Policy by its last recognised
+// token, the one browsers apply
+func mozillaReferrerPolicy(header http.Header) ScoreItem {
+	best := ""
+	for _, token := range strings.Split(header.Get("Referrer-Policy"), ",") {
+		token = strings.ToLower(strings.TrimSpace(token))
+		if _, ok := referrerPolicyStrength[token]; ok {
+			best = token
+		}
+	}
+
+	switch best {
+	case "no-referrer", "same-origin", "strict-origin", "strict-origin-when-cross-origin":
+		return ScoreItem{Label: "Referrer-Policy: set to a private policy", Points: 5}
+	case "", "no-referrer-when-downgrade":
+		return ScoreItem{Label: "Referrer-Policy: not implemented or no-referrer-when-downgrade", Points: 0}
+	default:
+		return ScoreItem{Label: "Referrer-Policy: set to an unsafe policy", Points: -5}
+	}
+}
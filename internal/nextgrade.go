@@ -0,0 +1,150 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// httpsFix names the fix of serving the site over HTTPS in NextGrade.Fixes
+const httpsFix = "HTTPS"
+
+// maxNextGradeCandidates caps how many header fixes ComputeNextGrade
+// combines; it searches every subset, so the work doubles with each one.
+// The built-in definitions never exceed it.
+const maxNextGradeCandidates = 12
+
+// ErrInvalidSummary is returned for a submitted summary that names unknown
+// headers, repeats one or lists more headers than are checked
+var ErrInvalidSummary = errors.New("invalid summary")
+
+// NextGrade is the smallest set of fixes that lifts a result into the
+// next grade band
+type NextGrade struct {
+	Current        string   `json:"current"`
+	Target         string   `json:"target"`
+	PointsNeeded   int      `json:"pointsNeeded"`
+	Fixes          []string `json:"fixes"`
+	ProjectedScore int      `json:"projectedScore"`
+}
+
+// nextGradeFloor returns the grade above the given one and its minimum score
+func nextGradeFloor(grade string) (string, int, bool) {
+	floors := gradeFloors()
+	for i, floor := range floors {
+		if floor.grade == grade {
+			if i == 0 {
+				return "", 0, false
+			}
+			return floors[i-1].grade, floors[i-1].score, true
+		}
+	}
+	if grade != "F" {
+		return "", 0, false
+	}
+	// F sits below every listed floor
+	last := floors[len(floors)-1]
+	return last.grade, last.score, true
+}
+
+// ValidateSummary checks a summary submitted by a client: every entry must
+// name a distinct checked header
+func ValidateSummary(summary []SecurityHeader) error {
+	if len(summary) > len(defaultSecurityHeaders) {
+		return fmt.Errorf("%w: at most %d headers are checked", ErrInvalidSummary, len(defaultSecurityHeaders))
+	}
+	seen := make(map[string]bool, len(summary))
+	for _, header := range summary {
+		if !knownHeader(header.Name) {
+			return fmt.Errorf("%w: unknown header %q", ErrInvalidSummary, header.Name)
+		}
+		if seen[header.Name] {
+			return fmt.Errorf("%w: header %s is listed more than once", ErrInvalidSummary, header.Name)
+		}
+		seen[header.Name] = true
+	}
+	return nil
+}
+
+// ComputeNextGrade searches for the fewest header fixes (a header earning
+// its full weight, or serving over HTTPS) that reach the next grade band,
+// preferring the highest projected score among equally small sets. Grade
+// caps apply to the projections. At most maxNextGradeCandidates header fixes
+// are considered. It returns nil for results already graded A or graded by
+// another profile.
+func ComputeNextGrade(result *AnalysisResult) *NextGrade {
+	target, floor, ok := nextGradeFloor(result.Grade)
+	if !ok {
+		return nil
+	}
+
+	https := result.servedOverHTTPS()
+	candidates := make([]string, 0, len(result.Summary)+1)
+	indexes := make(map[string]int)
+	for i, header := range result.Summary {
+		if _, seen := indexes[header.Name]; seen || !nextGradeCandidate(header) {
+			continue
+		}
+		candidates = append(candidates, header.Name)
+		indexes[header.Name] = i
+	}
+	if len(candidates) > maxNextGradeCandidates {
+		// Keep the fixes worth the most points
+		sort.SliceStable(candidates, func(i, j int) bool {
+			return result.Summary[indexes[candidates[i]]].Weight > result.Summary[indexes[candidates[j]]].Weight
+		})
+		candidates = candidates[:maxNextGradeCandidates]
+	}
+	if !https {
+		candidates = append(candidates, httpsFix)
+	}
+
+	deduction := result.deduction()
+	var best *NextGrade
+	for mask := 1; mask < 1<<len(candidates); mask++ {
+		fixed := make([]SecurityHeader, len(result.Summary))
+		copy(fixed, result.Summary)
+		fixedHTTPS := https
+		fixes := make([]string, 0, len(candidates))
+
+		for i, name := range candidates {
+			if mask&(1<<i) == 0 {
+				continue
+			}
+			fixes = append(fixes, name)
+			if name == httpsFix {
+				fixedHTTPS = true
+				continue
+			}
+			fixed[indexes[name]].Present = true
+			fixed[indexes[name]].Empty = false
+			fixed[indexes[name]].Awarded = fixed[indexes[name]].Weight
+		}
+
+		score := max(0, computeScore(fixed, fixedHTTPS)-deduction)
+		if grade, _ := capGrade(calculateGrade(score), fixed, fixedHTTPS); grade > target {
+			continue
+		}
+		if best == nil || len(fixes) < len(best.Fixes) ||
+			(len(fixes) == len(best.Fixes) && score > best.ProjectedScore) {
+			best = &NextGrade{
+				Current:        result.Grade,
+				Target:         target,
+				PointsNeeded:   max(0, floor-result.Score),
+				Fixes:          fixes,
+				ProjectedScore: score,
+			}
+		}
+	}
+
+	return best
+}
+
+// nextGradeCandidate reports whether fixing header can raise the score or
+// lift a grade cap
+func nextGradeCandidate(header SecurityHeader) bool {
+	if header.Present && !header.Empty && header.Awarded >= header.Weight {
+		return false
+	}
+	return header.Weight > 0 || header.tier() == Critical
+}
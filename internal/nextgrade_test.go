@@ -0,0 +1,117 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"testing"
+)
+
+func TestComputeNextGrade(t *testing.T) {
+	tests := []struct {
+		name   string
+		header http.Header
+		https  bool
+		fixes  []string
+	}{
+		{
+			name:   "missing critical headers",
+			header: http.Header{"Strict-Transport-Security": {"max-age=63072000"}},
+			https:  true,
+		},
+		{
+			name:   "plain HTTP",
+			header: http.Header{"X-Content-Type-Options": {"nosniff"}, "X-Frame-Options": {"DENY"}},
+			fixes:  []string{httpsFix},
+		},
+		{
+			name: "empty critical header",
+			header: http.Header{
+				"Strict-Transport-Security": {"max-age=63072000"},
+				"X-Content-Type-Options":    {"nosniff"},
+				"X-Frame-Options":           {""},
+				"Content-Security-Policy":   {"default-src 'self'; object-src 'none'"},
+				"Referrer-Policy":           {"no-referrer"},
+			},
+			https: true,
+			fixes: []string{"X-Frame-Options"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := AnalyzeHeaders(tt.header, tt.https)
+			next := ComputeNextGrade(result)
+			if next == nil {
+				t.Fatalf("ComputeNextGrade returned nil for grade %s", result.Grade)
+			}
+			for _, fix := range tt.fixes {
+				if !slices.Contains(next.Fixes, fix) {
+					t.Errorf("fixes = %v, want them to include %s", next.Fixes, fix)
+				}
+			}
+
+			// Applying the fixes must reach the promised grade
+			fixed := slices.Clone(result.Summary)
+			https := tt.https
+			for _, fix := range next.Fixes {
+				if fix == httpsFix {
+					https = true
+					continue
+				}
+				for i := range fixed {
+					if fixed[i].Name == fix {
+						fixed[i].Present, fixed[i].Empty, fixed[i].Awarded = true, false, fixed[i].Weight
+					}
+				}
+			}
+			score := max(0, computeScore(fixed, https)-result.deduction())
+			if grade, _ := capGrade(calculateGrade(score), fixed, https); grade > next.Target {
+				t.Errorf("fixes %v reach grade %s, want %s", next.Fixes, grade, next.Target)
+			}
+		})
+	}
+}
+
+func TestComputeNextGradeBoundsCandidates(t *testing.T) {
+	summary := make([]SecurityHeader, 40)
+	for i := range summary {
+		summary[i] = SecurityHeader{Name: fmt.Sprintf("X-Custom-%d", i), Weight: i + 1, Tier: Recommended}
+	}
+	result := &AnalysisResult{Summary: summary, HTTPS: true, Grade: "F"}
+
+	next := ComputeNextGrade(result)
+	if next == nil {
+		t.Fatal("ComputeNextGrade returned nil")
+	}
+	if len(next.Fixes) > maxNextGradeCandidates {
+		t.Errorf("got %d fixes, want at most %d", len(next.Fixes), maxNextGradeCandidates)
+	}
+}
+
+func TestValidateSummary(t *testing.T) {
+	known := SecurityHeader{Name: "X-Frame-Options"}
+	tests := []struct {
+		name    string
+		summary []SecurityHeader
+		valid   bool
+	}{
+		{"checked headers", []SecurityHeader{known, {Name: "Referrer-Policy"}}, true},
+		{"unknown header", []SecurityHeader{{Name: "X-Custom"}}, false},
+		{"duplicate header", []SecurityHeader{known, known}, false},
+		{"too many headers", slices.Repeat([]SecurityHeader{known}, len(defaultSecurityHeaders)+1), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateSummary(tt.summary)
+			if tt.valid && err != nil {
+				t.Errorf("ValidateSummary returned %v, want nil", err)
+			}
+			if !tt.valid && !errors.Is(err, ErrInvalidSummary) {
+				t.Errorf("ValidateSummary returned %v, want ErrInvalidSummary", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	neturl "net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidURL is returned for a target URL that cannot be analyzed
+var ErrInvalidURL = errors.New("invalid URL")
+
+// schemePattern matches a URL that starts with a scheme
+var schemePattern = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9+.-]*://`)
+
+// normalizeURL validates a target URL and returns the form that is fetched:
+// https:// is assumed when the scheme is missing, the scheme and host are
+// lowercased, a default port and the fragment are dropped. URLs that are
+// malformed, lack a host or carry credentials are rejected with an error
+// wrapping ErrInvalidURL; an IP address the analyzer may not connect to is
+// rejected with ErrBlockedAddress.
+func normalizeURL(raw string) (string, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return "", fmt.Errorf("%w: URL is empty", ErrInvalidURL)
+	}
+	if !schemePattern.MatchString(raw) {
+		raw = "https://" + raw
+	}
+
+	target, err := neturl.Parse(raw)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidURL, errors.Unwrap(err))
+	}
+
+	target.Scheme = strings.ToLower(target.Scheme)
+	if target.Scheme != "http" && target.Scheme != "https" {
+		return "", fmt.Errorf("%w: scheme must be http or https, not %q", ErrInvalidURL, target.Scheme)
+	}
+	if target.User != nil {
+		return "", fmt.Errorf("%w: credentials in the URL are not allowed", ErrInvalidURL)
+	}
+
+	host := strings.ToLower(target.Hostname())
+	if host == "" {
+		return "", fmt.Errorf("%w: host is missing", ErrInvalidURL)
+	}
+	if ip := net.ParseIP(host); ip != nil && !addressAllowed(ip) {
+		return "", fmt.Errorf("%w: %s", ErrBlockedAddress, host)
+	}
+
+	port := target.Port()
+	if number, err := strconv.Atoi(port); port != "" && (err != nil || number < 1 || number > 65535) {
+		return "", fmt.Errorf("%w: port %s is out of range", ErrInvalidURL, port)
+	}
+	target.Host = strings.ToLower(hostWithoutPort(target))
+	if port != "" && !(target.Scheme == "http" && port == "80") && !(target.Scheme == "https" && port == "443") {
+		target.Host += ":" + port
+	}
+	target.Fragment = ""
+	target.RawFragment = ""
+
+	return target.String(), nil
+}
+
+// isHTTPS reports whether url uses the https scheme, compared on the parsed
+// scheme so that upper-case schemes, ports and IPv6 literals do not matter
+func isHTTPS(url string) bool {
+	target, err := neturl.Parse(url)
+	return err == nil && strings.EqualFold(target.Scheme, "https")
+}
+
+// hostWithoutPort returns the host of target without its port, keeping the
+// brackets around an IPv6 literal
+func hostWithoutPort(target *neturl.URL) string {
+	host := target.Hostname()
+	if strings.Contains(host, ":") {
+		return "[" + host + "]"
+	}
+	return host
+}
@@ -0,0 +1,239 @@
+package internal
+
+import (
+	"encoding"
+	"encoding/json"
+	"net/http"
+	"path"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// OpenAPIOperation describes one endpoint for the OpenAPI document
+type OpenAPIOperation struct {
+	Method  string
+	Path    string
+	Summary string
+
+	// Query lists the query parameters the endpoint accepts
+	Query []OpenAPIParameter
+
+	// Request is a value of the JSON request body type, or nil when the
+	// endpoint takes no body
+	Request any
+
+	// Response is a value of the success response type. It is described
+	// as JSON unless ContentType names another media type, which is then
+	// documented as a plain string.
+	Response    any
+	ContentType string
+
+	// Errors lists the error statuses, each answered with the error type
+	Errors []int
+}
+
+// OpenAPIParameter is a query parameter of an operation
+type OpenAPIParameter struct {
+	Name        string
+	Type        string
+	Description string
+}
+
+// OpenAPIDocument builds an OpenAPI 3 document for the operations, served
+// below server. The request and response schemas are derived from the Go
+// types by reflection, following their json tags, so they stay in sync with
+// the structs. Fields a handler insists on carry an openapi:"required" tag.
+func OpenAPIDocument(title, version, server string, errorType any, ops []OpenAPIOperation) map[string]any {
+	builder := &schemaBuilder{schemas: make(map[string]any), names: make(map[reflect.Type]string)}
+	errorSchema := builder.schema(reflect.TypeOf(errorType))
+
+	paths := make(map[string]map[string]any)
+	for _, op := range ops {
+		operation := map[string]any{
+			"summary":   op.Summary,
+			"responses": builder.responses(op, errorSchema),
+		}
+		if len(op.Query) > 0 {
+			parameters := make([]map[string]any, 0, len(op.Query))
+			for _, param := range op.Query {
+				parameters = append(parameters, map[string]any{
+					"name":        param.Name,
+					"in":          "query",
+					"description": param.Description,
+					"schema":      map[string]any{"type": param.Type},
+				})
+			}
+			operation["parameters"] = parameters
+		}
+		if op.Request != nil {
+			operation["requestBody"] = map[string]any{
+				"required": true,
+				"content":  jsonContent(builder.schema(reflect.TypeOf(op.Request))),
+			}
+		}
+
+		if paths[op.Path] == nil {
+			paths[op.Path] = make(map[string]any)
+		}
+		paths[op.Path][strings.ToLower(op.Method)] = operation
+	}
+
+	if server == "" {
+		server = "/"
+	}
+	return map[string]any{
+		"openapi":    "3.0.3",
+		"info":       map[string]any{"title": title, "version": version},
+		"servers":    []map[string]any{{"url": server}},
+		"paths":      paths,
+		"components": map[string]any{"schemas": builder.schemas},
+	}
+}
+
+// responses describes the success response of op and its error statuses
+func (b *schemaBuilder) responses(op OpenAPIOperation, errorSchema map[string]any) map[string]any {
+	success := map[string]any{"description": "Success"}
+	switch {
+	case op.ContentType != "":
+		success["content"] = map[string]any{op.ContentType: map[string]any{"schema": map[string]any{"type": "string"}}}
+	case op.Response != nil:
+		success["content"] = jsonContent(b.schema(reflect.TypeOf(op.Response)))
+	}
+
+	responses := map[string]any{"200": success}
+	for _, status := range op.Errors {
+		responses[strconv.Itoa(status)] = map[string]any{
+			"description": http.StatusText(status),
+			"content":     jsonContent(errorSchema),
+		}
+	}
+	return responses
+}
+
+// jsonContent is an OpenAPI content map holding schema as JSON
+func jsonContent(schema map[string]any) map[string]any {
+	return map[string]any{"application/json": map[string]any{"schema": schema}}
+}
+
+var (
+	timeType          = reflect.TypeOf(time.Time{})
+	rawMessageType    = reflect.TypeOf(json.RawMessage{})
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// schemaBuilder derives JSON schemas from Go types, collecting named
+// structs as reusable components
+type schemaBuilder struct {
+	schemas map[string]any
+	names   map[reflect.Type]string
+}
+
+// schema returns the schema of values of t as encoding/json marshals them
+func (b *schemaBuilder) schema(t reflect.Type) map[string]any {
+	switch {
+	case t == timeType:
+		return map[string]any{"type": "string", "format": "date-time"}
+	case t == rawMessageType:
+		return map[string]any{}
+	case t.Kind() != reflect.Pointer && t.Implements(textMarshalerType):
+		return map[string]any{"type": "string"}
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		return b.schema(t.Elem())
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]any{"type": "string", "format": "byte"}
+		}
+		return map[string]any{"type": "array", "items": b.schema(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": b.schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return b.object(t)
+		}
+		return map[string]any{"$ref": "#/components/schemas/" + b.component(t)}
+	default:
+		return map[string]any{}
+	}
+}
+
+// component registers the named struct t as a component and returns its
+// name, qualified by package when another type already took the plain name
+func (b *schemaBuilder) component(t reflect.Type) string {
+	if name, ok := b.names[t]; ok {
+		return name
+	}
+
+	name := t.Name()
+	if _, taken := b.schemas[name]; taken {
+		name = path.Base(t.PkgPath()) + "." + name
+	}
+	b.names[t] = name
+	// Reserve the name first so recursive types refer back to it
+	b.schemas[name] = nil
+	b.schemas[name] = b.object(t)
+	return name
+}
+
+// object describes the struct t by its JSON fields. Only fields tagged
+// openapi:"required" are listed as required; encoding/json accepts bodies
+// without the others, and handlers treat them as optional.
+func (b *schemaBuilder) object(t reflect.Type) map[string]any {
+	properties := make(map[string]any)
+	var required []string
+	b.fields(t, properties, &required)
+
+	object := map[string]any{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		object["required"] = required
+	}
+	return object
+}
+
+// fields adds the JSON fields of the struct t to properties, inlining
+// embedded structs as encoding/json does
+func (b *schemaBuilder) fields(t reflect.Type, properties map[string]any, required *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" || (!field.IsExported() && !field.Anonymous) {
+			continue
+		}
+
+		name, _, _ := strings.Cut(tag, ",")
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				b.fields(embedded, properties, required)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+		properties[name] = b.schema(field.Type)
+		if field.Tag.Get("openapi") == "required" {
+			*required = append(*required, name)
+		}
+	}
+}
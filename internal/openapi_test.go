@@ -0,0 +1,27 @@
+package internal
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestOpenAPIRequiredFields(t *testing.T) {
+	type request struct {
+		URL      string `json:"url" openapi:"required"`
+		Filter   string `json:"filter"`
+		MaxPages int    `json:"maxPages,omitempty"`
+	}
+
+	doc := OpenAPIDocument("test", "1.0", "", struct{}{}, []OpenAPIOperation{
+		{Method: "POST", Path: "/test", Request: request{}},
+	})
+	schemas := doc["components"].(map[string]any)["schemas"].(map[string]any)
+	schema := schemas["request"].(map[string]any)
+
+	if got, want := schema["required"], []string{"url"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("required = %v, want %v", got, want)
+	}
+	if properties := schema["properties"].(map[string]any); len(properties) != 3 {
+		t.Errorf("got %d properties, want 3", len(properties))
+	}
+}
@@ -0,0 +1,149 @@
+package internal
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// powerfulFeatures are the features a Permissions-Policy earns its weight
+// for locking down
+var powerfulFeatures = []string{"camera", "microphone", "geolocation"}
+
+// permissionsSelfCredit is the share of a powerful feature's credit earned
+// for restricting it to the site's own origin instead of disabling it
+const permissionsSelfCredit = 0.5
+
+// trackingFeatures are the ad-targeting APIs privacy-conscious sites opt out of
+var trackingFeatures = []string{"browsing-topics", "interest-cohort"}
+
+// defaultRequiredPermissions are the features a Permissions-Policy must
+// declare unless configured otherwise
+var defaultRequiredPermissions = []string{"camera", "microphone", "geolocation"}
+
+// parsePermissionsPolicy splits a Permissions-Policy value such as
+// `camera=(), geolocation=(self "https://maps.example")` into a map of
+// feature to allowlist
+func parsePermissionsPolicy(value string) map[string]string {
+	policy := make(map[string]string)
+	for _, directive := range strings.Split(value, ",") {
+		feature, allowlist, found := strings.Cut(strings.TrimSpace(directive), "=")
+		if !found {
+			continue
+		}
+		policy[strings.ToLower(strings.TrimSpace(feature))] = strings.TrimSpace(allowlist)
+	}
+	return policy
+}
+
+// parseFeaturePolicy splits a legacy Feature-Policy value such as
+// `camera 'none'; geolocation 'self'` into a map of feature to allowlist
+func parseFeaturePolicy(value string) map[string]string {
+	policy := make(map[string]string)
+	for _, directive := range strings.Split(value, ";") {
+		fields := strings.Fields(directive)
+		if len(fields) == 0 {
+			continue
+		}
+		policy[strings.ToLower(fields[0])] = strings.Join(fields[1:], " ")
+	}
+	return policy
+}
+
+// featureDisabled reports whether an allowlist blocks a feature everywhere
+func featureDisabled(allowlist string) bool {
+	return allowlist == "()" || allowlist == "'none'"
+}
+
+// featureSelfOnly reports whether an allowlist limits a feature to the
+// site's own origin
+func featureSelfOnly(allowlist string) bool {
+	return allowlist == "(self)" || allowlist == "self" || allowlist == "'self'"
+}
+
+// permissionsPolicy returns the parsed policy, preferring the modern header
+// over the legacy Feature-Policy
+func permissionsPolicy(header http.Header) map[string]string {
+	if value := header.Get("Permissions-Policy"); value != "" {
+		return parsePermissionsPolicy(value)
+	}
+	return parseFeaturePolicy(header.Get("Feature-Policy"))
+}
+
+// checkPermissionsPolicy runs the Permissions-Policy value checks and
+// reports the parsed directives
+func checkPermissionsPolicy(item *SecurityHeader, header http.Header) {
+	item.Directives = permissionsPolicy(header)
+	if header.Get("Permissions-Policy") == "" {
+		item.Issues = append(item.Issues, "the legacy Feature-Policy syntax is used; send Permissions-Policy, e.g. camera=(), instead")
+	}
+	checkPowerfulFeatures(item, header)
+	checkRequiredPermissions(item, header)
+	checkTrackingFeatures(item, header)
+}
+
+// checkPowerfulFeatures awards the weight by how well the policy locks down
+// the powerful features: each disabled one earns its full share, each
+// limited to the site's own origin earns permissionsSelfCredit of it, and
+// each left open or undeclared earns nothing. Undeclared features that are
+// also required are left to checkRequiredPermissions to report.
+func checkPowerfulFeatures(item *SecurityHeader, header http.Header) {
+	policy := permissionsPolicy(header)
+	credit := 0.0
+	var selfOnly, open []string
+	for _, feature := range powerfulFeatures {
+		switch allowlist := policy[feature]; {
+		case featureDisabled(allowlist):
+			credit++
+		case featureSelfOnly(allowlist):
+			credit += permissionsSelfCredit
+			selfOnly = append(selfOnly, feature)
+		default:
+			// Undeclared required features are reported by checkRequiredPermissions
+			if _, declared := policy[feature]; declared || !requiredPermission(feature) {
+				open = append(open, feature)
+			}
+		}
+	}
+
+	item.Awarded = int(math.Round(float64(item.Weight) * credit / float64(len(powerfulFeatures))))
+	if len(open) > 0 {
+		item.Issues = append(item.Issues, fmt.Sprintf("powerful features left unrestricted: %s; disable them with e.g. %s=() (%d of %d awarded)", strings.Join(open, ", "), open[0], item.Awarded, item.Weight))
+	}
+	if len(selfOnly) > 0 {
+		item.Issues = append(item.Issues, fmt.Sprintf("powerful features still allowed for the site's own origin: %s; disabling them earns full credit (%d of %d awarded)", strings.Join(selfOnly, ", "), item.Awarded, item.Weight))
+	}
+}
+
+// checkRequiredPermissions reports the configured required features the
+// policy does not declare, powerful or not. It does not affect the awarded
+// weight.
+func checkRequiredPermissions(item *SecurityHeader, header http.Header) {
+	policy := permissionsPolicy(header)
+	for _, feature := range config.RequiredPermissions {
+		if _, declared := policy[strings.ToLower(feature)]; !declared {
+			item.Issues = append(item.Issues, fmt.Sprintf("required feature %s is not declared (e.g. %s=())", feature, feature))
+		}
+	}
+}
+
+// requiredPermission reports whether feature is one of the configured
+// required features
+func requiredPermission(feature string) bool {
+	return slices.ContainsFunc(config.RequiredPermissions, func(required string) bool {
+		return strings.EqualFold(required, feature)
+	})
+}
+
+// checkTrackingFeatures reports ad-targeting features the policy leaves
+// enabled. It is informational and does not affect the awarded weight.
+func checkTrackingFeatures(item *SecurityHeader, header http.Header) {
+	policy := permissionsPolicy(header)
+	for _, feature := range trackingFeatures {
+		if !featureDisabled(policy[feature]) {
+			item.Issues = append(item.Issues, fmt.Sprintf("tracking feature %s is not disabled (set %s=())", feature, feature))
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package internal
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestCheckPermissionsPolicyRequired(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    string
+		required []string
+		issues   []string
+	}{
+		{
+			name:     "default list flags missing camera",
+			value:    "microphone=(), geolocation=()",
+			required: defaultRequiredPermissions,
+			issues:   []string{"required feature camera is not declared (e.g. camera=())"},
+		},
+		{
+			name:     "default list satisfied",
+			value:    "camera=(), microphone=(), geolocation=()",
+			required: defaultRequiredPermissions,
+		},
+		{
+			name:     "extended list",
+			value:    "camera=(), microphone=(), geolocation=()",
+			required: append([]string{"unload"}, defaultRequiredPermissions...),
+			issues:   []string{"required feature unload is not declared (e.g. unload=())"},
+		},
+		{
+			name:     "undeclared powerful feature not required",
+			value:    "microphone=(), geolocation=()",
+			required: nil,
+			issues:   []string{"powerful features left unrestricted: camera"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			saved := config.RequiredPermissions
+			config.RequiredPermissions = tt.required
+			t.Cleanup(func() { config.RequiredPermissions = saved })
+
+			header := http.Header{"Permissions-Policy": {tt.value}}
+			item := SecurityHeader{Name: "Permissions-Policy", Present: true, Weight: 9, Awarded: 9}
+			checkPowerfulFeatures(&item, header)
+			checkRequiredPermissions(&item, header)
+
+			if len(item.Issues) != len(tt.issues) {
+				t.Fatalf("issues = %q, want %d issues", item.Issues, len(tt.issues))
+			}
+			for i, want := range tt.issues {
+				if !strings.HasPrefix(item.Issues[i], want) {
+					t.Errorf("issue %d = %q, want it to start with %q", i, item.Issues[i], want)
+				}
+			}
+		})
+	}
+}
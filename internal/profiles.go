@@ -0,0 +1,132 @@
+package internal
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// ScoringProfile selects how a result is scored; the zero value is the
+// default model
+type ScoringProfile string
+
+const (
+	// ProfileDefault is the weighted header model described in the README
+	ProfileDefault ScoringProfile = ""
+	// ProfileMozilla mirrors the Mozilla Observatory's point modifiers
+	ProfileMozilla ScoringProfile = "mozilla"
+)
+
+// ErrUnknownProfile is returned for a scoring profile that does not exist
+var ErrUnknownProfile = errors.New("profile must be one of default, mozilla")
+
+// scoringInput is what a scoring profile scores: the analyzed result with
+// its summary and cookies, the raw response headers and, for profiles that
+// need it, how plain HTTP is upgraded
+type scoringInput struct {
+	result  *AnalysisResult
+	header  http.Header
+	upgrade *UpgradeResult
+}
+
+// scoringProfile computes a score as a breakdown of line items and maps
+// scores to grades
+type scoringProfile struct {
+	score func(in scoringInput) *ScoreBreakdown
+	grade func(score int) string
+}
+
+// scoringProfiles holds every profile that can be selected
+var scoringProfiles = map[ScoringProfile]scoringProfile{
+	ProfileDefault: {score: defaultScore, grade: calculateGrade},
+	ProfileMozilla: {score: mozillaScore, grade: mozillaGrade},
+}
+
+// ValidProfile reports whether profile is a known scoring profile.
+// "default" names the default profile explicitly.
+func ValidProfile(profile ScoringProfile) bool {
+	_, ok := scoringProfiles[normalizeProfile(profile)]
+	return ok
+}
+
+// normalizeProfile maps the explicit "default" to the zero value
+func normalizeProfile(profile ScoringProfile) ScoringProfile {
+	if strings.EqualFold(string(profile), "default") {
+		return ProfileDefault
+	}
+	return ScoringProfile(strings.ToLower(string(profile)))
+}
+
+// defaultScore is the default model: the header score, the HTTPS base and
+// the tier bonuses, less disclosure, cookie and CORS penalties
+func defaultScore(in scoringInput) *ScoreBreakdown {
+	return newScoreBreakdown(append(scoreItems(in.result.Summary, in.result.HTTPS), in.result.deductionItems()...))
+}
+
+// applyProfile rescores result with a profile other than the default one.
+// The grade cap and the next-grade plan belong to the default model, so
+// they are dropped; the risk level and potential gains follow the new grade
+// and score.
+func applyProfile(result *AnalysisResult, in scoringInput, profile ScoringProfile) {
+	profile = normalizeProfile(profile)
+	if profile == ProfileDefault {
+		return
+	}
+
+	scoring := scoringProfiles[profile]
+	result.Profile = profile
+	result.ScoreBreakdown = scoring.score(in)
+	result.Score = result.ScoreBreakdown.Total
+	result.Grade = scoring.grade(result.Score)
+	result.GradeCapReason = ""
+	result.GradeLabel = gradeLabel(result.Score)
+	result.NextGrade = nil
+	result.RiskLevel, result.RiskFactors = classifyRisk(result, in.header)
+	result.PotentialGains = profileGains(scoring, in)
+}
+
+// profileGains estimates the points scoring awards for fixing every missing
+// or partial header of each tier, by rescoring the response with those
+// headers set to their recommended values
+func profileGains(scoring scoringProfile, in scoringInput) map[string]int {
+	current := scoring.score(in).Total
+	gains := make(map[string]int)
+
+	for _, tier := range []SecurityHeaderTier{Critical, Important, Recommended} {
+		fixed := in
+		fixed.header = in.header.Clone()
+		if fixed.header == nil {
+			fixed.header = make(http.Header)
+		}
+		for _, header := range in.result.Summary {
+			if header.tier() != tier || (header.Present && header.Awarded >= header.Weight) {
+				continue
+			}
+			if value, ok := recommendedValue(header.Name, in.header.Get(header.Name)); ok {
+				fixed.header.Set(header.Name, value)
+			}
+		}
+		gains[tier.String()] = scoring.score(fixed).Total - current
+	}
+
+	return gains
+}
+
+// ValidGrade reports whether g is a grade one of the scoring profiles
+// assigns
+func ValidGrade(g string) bool {
+	if g == "F" {
+		return true
+	}
+	for _, floor := range gradeFloors() {
+		if floor.grade == g {
+			return true
+		}
+	}
+	for _, floor := range mozillaGradeFloors {
+		if floor.grade == g {
+			return true
+		}
+	}
+	return false
+}
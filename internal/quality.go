@@ -0,0 +1,20 @@
+package internal
+
+import "math"
+
+// configurationQuality scores the present headers by the strength of their
+// values alone, as the share of their weight they were awarded (0-100).
+// It reports false when no checked header is present.
+func configurationQuality(summary []SecurityHeader) (int, bool) {
+	weight, awarded := 0, 0
+	for _, header := range summary {
+		if header.Present {
+			weight += header.Weight
+			awarded += header.Awarded
+		}
+	}
+	if weight == 0 {
+		return 0, false
+	}
+	return int(math.Round(float64(awarded) * 100 / float64(weight))), true
+}
@@ -0,0 +1,29 @@
+package internal
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestRateLimiter(t *testing.T) {
+	limiter := NewRateLimiter(2, 200*time.Millisecond)
+	ctx := context.Background()
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if err := limiter.Wait(ctx); err != nil {
+			t.Fatalf("Wait returned %v", err)
+		}
+	}
+	// The first two fill the burst; the third waits for a refill
+	if elapsed := time.Since(start); elapsed < 80*time.Millisecond {
+		t.Errorf("three waits took %s, want the third to wait about 100ms", elapsed)
+	}
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if err := limiter.Wait(cancelled); err == nil {
+		t.Error("Wait on an empty bucket with a cancelled context returned nil")
+	}
+}
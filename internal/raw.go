@@ -0,0 +1,162 @@
+package internal
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	neturl "net/url"
+	"strings"
+)
+
+// ErrHostNotAllowed is returned when a raw request targets a host outside
+// the configured allowlist
+var ErrHostNotAllowed = errors.New("target host is not in the raw request allowlist")
+
+// RawHeader is a single request header line, sent verbatim
+type RawHeader struct {
+	Name  string `json:"name" openapi:"required"`
+	Value string `json:"value"`
+}
+
+// RawRequest is a hand-crafted HTTP/1.1 request. Headers are written in
+// order and may repeat, so conflicting framing headers can be tested.
+type RawRequest struct {
+	Target  string      `json:"target" openapi:"required"`
+	Method  string      `json:"method" openapi:"required"`
+	Path    string      `json:"path"`
+	Headers []RawHeader `json:"headers"`
+	Body    string      `json:"body"`
+}
+
+// RawAnalysis is the analysis of the response to a raw request
+type RawAnalysis struct {
+	Request    string          `json:"request"`
+	StatusCode int             `json:"statusCode"`
+	Result     *AnalysisResult `json:"result"`
+}
+
+// rawHostAllowed reports whether host is in the raw request allowlist
+func rawHostAllowed(host string) bool {
+	for _, allowed := range config.RawRequestAllowedHosts {
+		if strings.EqualFold(host, allowed) {
+			return true
+		}
+	}
+	return false
+}
+
+// AnalyzeRawRequest writes the request byte for byte to its target over a
+// fresh connection and analyzes the response headers
+func AnalyzeRawRequest(ctx context.Context, raw RawRequest) (*RawAnalysis, error) {
+	target, err := neturl.Parse(raw.Target)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		return nil, fmt.Errorf("target must be an absolute http or https URL")
+	}
+	if !rawHostAllowed(target.Hostname()) {
+		return nil, ErrHostNotAllowed
+	}
+
+	payload, err := buildRawRequest(target.Host, raw)
+	if err != nil {
+		return nil, err
+	}
+
+	addr := target.Host
+	if target.Port() == "" {
+		port := "80"
+		if target.Scheme == "https" {
+			port = "443"
+		}
+		addr = net.JoinHostPort(target.Hostname(), port)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, config.ClientTimeout)
+	defer cancel()
+
+	release, err := acquireFetch(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	conn, err := newDialer().DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	if target.Scheme == "https" {
+		conn = tls.Client(conn, &tls.Config{ServerName: target.Hostname(), InsecureSkipVerify: true})
+	}
+	defer conn.Close()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	if _, err := conn.Write([]byte(payload)); err != nil {
+		return nil, err
+	}
+
+	resp, err := http.ReadResponse(bufio.NewReader(conn), &http.Request{Method: strings.ToUpper(raw.Method)})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	readBody(resp)
+
+	url := target.Scheme + "://" + target.Host + raw.Path
+	return &RawAnalysis{
+		Request:    payload,
+		StatusCode: resp.StatusCode,
+		Result:     scoreResponse(url, securityHeaders, resp, nil),
+	}, nil
+}
+
+// buildRawRequest serializes the request. Host, Content-Length and
+// Connection are only added when the caller did not supply them.
+func buildRawRequest(host string, raw RawRequest) (string, error) {
+	if raw.Method == "" || strings.ContainsAny(raw.Method, " \r\n") {
+		return "", fmt.Errorf("method must be a single token")
+	}
+	if raw.Path == "" {
+		raw.Path = "/"
+	}
+	if strings.ContainsAny(raw.Path, " \r\n") {
+		return "", fmt.Errorf("path must not contain spaces or line breaks")
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %s HTTP/1.1\r\n", raw.Method, raw.Path)
+
+	has := func(name string) bool {
+		for _, header := range raw.Headers {
+			if strings.EqualFold(strings.TrimSpace(header.Name), name) {
+				return true
+			}
+		}
+		return false
+	}
+
+	if !has("Host") {
+		fmt.Fprintf(&b, "Host: %s\r\n", host)
+	}
+	for _, header := range raw.Headers {
+		if strings.ContainsAny(header.Name, "\r\n") || strings.ContainsAny(header.Value, "\r\n") {
+			return "", fmt.Errorf("header %q must not contain line breaks", header.Name)
+		}
+		fmt.Fprintf(&b, "%s: %s\r\n", header.Name, header.Value)
+	}
+	if raw.Body != "" && !has("Content-Length") && !has("Transfer-Encoding") {
+		fmt.Fprintf(&b, "Content-Length: %d\r\n", len(raw.Body))
+	}
+	if !has("Connection") {
+		b.WriteString("Connection: close\r\n")
+	}
+	b.WriteString("\r\n")
+	b.WriteString(raw.Body)
+
+	return b.String(), nil
+}
@@ -0,0 +1,72 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+)
+
+// maxReachabilityTimeout caps the time a reachability check may take, so it
+// stays cheap even when the analysis timeout is long
+const maxReachabilityTimeout = 5 * time.Second
+
+// Reachability tells whether a target answers at all, and where it ends up,
+// without fetching the body or scoring it
+type Reachability struct {
+	URL        string `json:"url"`
+	Reachable  bool   `json:"reachable"`
+	FinalURL   string `json:"finalUrl,omitempty"`
+	Scheme     string `json:"scheme,omitempty"`
+	StatusCode int    `json:"statusCode,omitempty"`
+	Redirects  int    `json:"redirects"`
+	ErrorKind  string `json:"errorKind,omitempty"`
+	Error      string `json:"error,omitempty"`
+	DurationMs int64  `json:"durationMs"`
+}
+
+// CheckReachable sends a HEAD request to url with the default analyzer
+func CheckReachable(ctx context.Context, url string) (*Reachability, error) {
+	return defaultAnalyzer.CheckReachable(ctx, url)
+}
+
+// CheckReachable sends a HEAD request to url, following redirects, and
+// reports whether any response came back, with its status and final
+// scheme. An invalid or blocked URL and a full fetch queue are returned as
+// errors; a target that cannot be fetched is reported as unreachable.
+func (a *Analyzer) CheckReachable(ctx context.Context, url string) (*Reachability, error) {
+	url, err := normalizeURL(url)
+	if err != nil {
+		return nil, err
+	}
+
+	chain := &RedirectChain{Hops: make([]RedirectHop, 0)}
+	client := followRedirects(newClient(a.transport, min(config.ClientTimeout, maxReachabilityTimeout)), chain, func() {})
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	reachability := &Reachability{URL: url}
+	start := time.Now()
+	resp, err := client.Do(req)
+	reachability.DurationMs = time.Since(start).Milliseconds()
+	reachability.Redirects = len(chain.Hops)
+	if err != nil {
+		err = fetchError(url, err)
+		if errors.Is(err, ErrBlockedAddress) || errors.Is(err, ErrFetchQueueTimeout) || errors.Is(err, context.Canceled) {
+			return nil, err
+		}
+		reachability.ErrorKind = errorKind(err)
+		reachability.Error = err.Error()
+		return reachability, nil
+	}
+	closeBody(resp)
+
+	reachability.Reachable = true
+	reachability.FinalURL = resp.Request.URL.String()
+	reachability.Scheme = resp.Request.URL.Scheme
+	reachability.StatusCode = resp.StatusCode
+	return reachability, nil
+}
@@ -0,0 +1,119 @@
+package internal
+
+import (
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// redactedValue replaces header values that must not be surfaced
+const redactedValue = "[REDACTED]"
+
+// defaultRedactedHeaders are never surfaced verbatim because they commonly
+// carry session or credential material
+var defaultRedactedHeaders = []string{
+	"Authorization",
+	"Proxy-Authorization",
+	"Cookie",
+	"Set-Cookie",
+	"X-Api-Key",
+	"X-Auth-Token",
+}
+
+// defaultRedactedPatterns match secrets that commonly end up in header
+// values: bearer tokens, JWTs, cloud and SaaS API keys, and key=value
+// credentials
+var defaultRedactedPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`(?i)\bbearer\s+[A-Za-z0-9\-._~+/]+=*`),
+	regexp.MustCompile(`\beyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*`),
+	regexp.MustCompile(`\bAKIA[0-9A-Z]{16}\b`),
+	regexp.MustCompile(`\bAIza[0-9A-Za-z_-]{35}\b`),
+	regexp.MustCompile(`\bgh[pousr]_[A-Za-z0-9]{36,}\b`),
+	regexp.MustCompile(`\bxox[abprs]-[A-Za-z0-9-]{10,}`),
+	regexp.MustCompile(`\b[rs]k_(?:live|test)_[A-Za-z0-9]{16,}\b`),
+	regexp.MustCompile(`(?i)\b(?:api[_-]?key|access[_-]?token|token|secret|password)=[^&;,\s]+`),
+}
+
+// redactValue hides the value of a redacted header entirely and masks
+// the parts of any other value that match a redaction pattern
+func redactValue(name, value string) string {
+	if isRedactedHeader(name) {
+		return redactedValue
+	}
+	for _, pattern := range config.RedactedPatterns {
+		value = pattern.ReplaceAllString(value, redactedValue)
+	}
+	return value
+}
+
+// isRedactedHeader reports whether the values of the named header are redacted
+func isRedactedHeader(name string) bool {
+	for _, redacted := range config.RedactedHeaders {
+		if strings.EqualFold(name, redacted) {
+			return true
+		}
+	}
+	return false
+}
+
+// redactSummary applies redaction to the values surfaced in summary
+// entries and to their issues and notes, which may quote those values
+func redactSummary(items []SecurityHeader) {
+	for i := range items {
+		items[i].Issues = redactMessages(items[i], items[i].Issues)
+		items[i].Notes = redactMessages(items[i], items[i].Notes)
+		items[i].Value = redactValue(items[i].Name, items[i].Value)
+		if items[i].Values == nil {
+			continue
+		}
+		// Values may share storage with the response headers
+		values := make([]string, len(items[i].Values))
+		for j, value := range items[i].Values {
+			values[j] = redactValue(items[i].Name, value)
+		}
+		items[i].Values = values
+	}
+}
+
+// redactMessages masks the parts of the messages of item that match a
+// redaction pattern, and any quoted value of a redacted header. It runs
+// before the item's own values are redacted.
+func redactMessages(item SecurityHeader, messages []string) []string {
+	if messages == nil {
+		return nil
+	}
+
+	var secrets []string
+	if isRedactedHeader(item.Name) {
+		for _, value := range append([]string{item.Value}, item.Values...) {
+			if value != "" {
+				secrets = append(secrets, value)
+			}
+		}
+	}
+
+	redacted := make([]string, len(messages))
+	for i, message := range messages {
+		for _, secret := range secrets {
+			message = strings.ReplaceAll(message, secret, redactedValue)
+		}
+		for _, pattern := range config.RedactedPatterns {
+			message = pattern.ReplaceAllString(message, redactedValue)
+		}
+		redacted[i] = message
+	}
+	return redacted
+}
+
+// collectHeaders copies every response header, redacting sensitive values
+func collectHeaders(header http.Header) map[string][]string {
+	all := make(map[string][]string, len(header))
+	for name, values := range header {
+		copied := make([]string, len(values))
+		for i, value := range values {
+			copied[i] = redactValue(name, value)
+		}
+		all[name] = copied
+	}
+	return all
+}
@@ -0,0 +1,69 @@
+package internal
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestRedactSummary(t *testing.T) {
+	const token = "token=s3cr3t"
+	tests := []struct {
+		name string
+		item SecurityHeader
+	}{
+		{
+			name: "pattern match in an issue",
+			item: SecurityHeader{
+				Name:   "X-Powered-By",
+				Value:  "Express " + token,
+				Issues: []string{`exposes "Express ` + token + `"`},
+				Notes:  []string{"seen " + token},
+			},
+		},
+		{
+			name: "redacted header quoted in an issue",
+			item: SecurityHeader{
+				Name:   "Authorization",
+				Value:  "Basic dXNlcjpwYXNz",
+				Values: []string{"Basic dXNlcjpwYXNz"},
+				Issues: []string{`unexpected value "Basic dXNlcjpwYXNz"`},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			secret := tt.item.Value
+			if !isRedactedHeader(tt.item.Name) {
+				secret = token
+			}
+			items := []SecurityHeader{tt.item}
+			redactSummary(items)
+
+			for _, text := range append(append([]string{items[0].Value}, items[0].Issues...), items[0].Notes...) {
+				if strings.Contains(text, secret) {
+					t.Errorf("%q still contains %q", text, secret)
+				}
+				if !strings.Contains(text, redactedValue) {
+					t.Errorf("%q is not marked %s", text, redactedValue)
+				}
+			}
+		})
+	}
+}
+
+func TestDisclosureIssuesOmitValue(t *testing.T) {
+	header := http.Header{"X-Powered-By": {"PHP/8.1.2 token=s3cr3t"}}
+	result := AnalyzeHeaders(header, true)
+	if len(result.Disclosures) == 0 {
+		t.Fatal("X-Powered-By was not reported as a disclosure")
+	}
+	for _, disclosure := range result.Disclosures {
+		for _, text := range append([]string{disclosure.Value}, disclosure.Issues...) {
+			if strings.Contains(text, "s3cr3t") {
+				t.Errorf("disclosure %s leaks the secret in %q", disclosure.Name, text)
+			}
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package internal
+
+import (
+	"fmt"
+	"net/http"
+	neturl "net/url"
+)
+
+// maxRedirects caps how many redirects are followed, to escape loops
+const maxRedirects = 10
+
+// RedirectHop is a redirect response on the way to the analyzed page
+type RedirectHop struct {
+	URL        string `json:"url"`
+	StatusCode int    `json:"statusCode"`
+	Location   string `json:"location"`
+}
+
+// RedirectChain describes the redirects followed before the final response
+// was scored
+type RedirectChain struct {
+	Hops            []RedirectHop `json:"hops"`
+	FinalURL        string        `json:"finalUrl"`
+	UpgradedToHTTPS bool          `json:"upgradedToHttps"`
+	Issues          []string      `json:"issues,omitempty"`
+}
+
+// followRedirects returns a copy of client that follows up to maxRedirects
+// redirects, recording each hop in chain and calling reset before every
+// new request
+func followRedirects(client *http.Client, chain *RedirectChain, reset func()) *http.Client {
+	following := *client
+	following.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if len(via) > maxRedirects {
+			return fmt.Errorf("stopped after %d redirects", maxRedirects)
+		}
+		chain.Hops = append(chain.Hops, RedirectHop{
+			URL:        via[len(via)-1].URL.String(),
+			StatusCode: req.Response.StatusCode,
+			Location:   req.URL.String(),
+		})
+		reset()
+		return nil
+	}
+	return &following
+}
+
+// finishRedirectChain records where the chain ended and flags plain HTTP
+// that is never upgraded and HTTPS that is downgraded
+func finishRedirectChain(chain *RedirectChain, start string, final *neturl.URL) {
+	chain.FinalURL = final.String()
+	startURL, err := neturl.Parse(start)
+	if err != nil {
+		return
+	}
+
+	switch {
+	case startURL.Scheme == "http" && final.Scheme == "https":
+		chain.UpgradedToHTTPS = true
+	case startURL.Scheme == "http":
+		chain.Issues = append(chain.Issues, "content is served over plain HTTP without a redirect to HTTPS")
+	case final.Scheme == "http":
+		chain.Issues = append(chain.Issues, "the redirects downgrade HTTPS to plain HTTP")
+	}
+}
@@ -0,0 +1,66 @@
+package internal
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// DefaultReferenceTTL is how long a reference analysis is reused
+const DefaultReferenceTTL = 10 * time.Minute
+
+// Baseline is the difference between a target and the reference server,
+// going from the reference to the target
+type Baseline struct {
+	Reference  string      `json:"reference"`
+	AnalyzedAt time.Time   `json:"analyzedAt"`
+	Diff       *Comparison `json:"diff"`
+}
+
+// ReferenceServer analyzes a server configured with the ideal headers and
+// caches the analysis so it can serve as a live comparison baseline
+type ReferenceServer struct {
+	url string
+	ttl time.Duration
+
+	mu         sync.Mutex
+	result     *AnalysisResult
+	analyzedAt time.Time
+}
+
+// NewReferenceServer creates a reference baseline for url whose analysis is
+// refreshed at most once per ttl
+func NewReferenceServer(url string, ttl time.Duration) *ReferenceServer {
+	return &ReferenceServer{url: url, ttl: ttl}
+}
+
+// analysis returns the cached reference analysis, refreshing it once it is
+// older than the TTL. Failed refreshes are not cached.
+func (r *ReferenceServer) analysis(ctx context.Context) (*AnalysisResult, time.Time, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.result != nil && time.Since(r.analyzedAt) < r.ttl {
+		return r.result, r.analyzedAt, nil
+	}
+
+	result, err := analyze(ctx, r.url, Options{})
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	r.result, r.analyzedAt = result, time.Now().UTC()
+	return r.result, r.analyzedAt, nil
+}
+
+// Compare diffs target against the reference analysis
+func (r *ReferenceServer) Compare(ctx context.Context, target *AnalysisResult) (*Baseline, error) {
+	reference, analyzedAt, err := r.analysis(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &Baseline{
+		Reference:  reference.URL,
+		AnalyzedAt: analyzedAt,
+		Diff:       Compare(reference, target),
+	}, nil
+}
@@ -0,0 +1,65 @@
+package internal
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strings"
+)
+
+// Strength classes of a Referrer-Policy token, weakest first
+const (
+	referrerUnknown = iota
+	referrerWeak
+	referrerAcceptable
+	referrerStrong
+)
+
+// referrerPolicyStrength classifies each Referrer-Policy token by how much
+// of the URL it leaks to other origins
+var referrerPolicyStrength = map[string]int{
+	"no-referrer":                     referrerStrong,
+	"strict-origin-when-cross-origin": referrerStrong,
+	"same-origin":                     referrerAcceptable,
+	"strict-origin":                   referrerAcceptable,
+	"origin":                          referrerAcceptable,
+	"origin-when-cross-origin":        referrerAcceptable,
+	"no-referrer-when-downgrade":      referrerWeak,
+	"unsafe-url":                      referrerWeak,
+}
+
+// referrerAcceptableCredit is the share of the weight an acceptable
+// Referrer-Policy earns
+const referrerAcceptableCredit = 0.5
+
+// checkReferrerPolicy awards weight by the strongest recognised token of
+// the comma-separated Referrer-Policy: full weight for a strong policy,
+// half for an acceptable one and nothing for a weak or unrecognised one
+func checkReferrerPolicy(item *SecurityHeader, header http.Header) {
+	best, bestToken := referrerUnknown, ""
+	for _, token := range strings.Split(header.Get("Referrer-Policy"), ",") {
+		token = strings.ToLower(strings.TrimSpace(token))
+		if strength := referrerPolicyStrength[token]; strength > best {
+			best, bestToken = strength, token
+		}
+	}
+
+	// Name the scored token when a fallback list was sent
+	label := bestToken
+	if strings.Contains(header.Get("Referrer-Policy"), ",") {
+		label = "strongest token " + bestToken
+	}
+
+	switch best {
+	case referrerStrong:
+	case referrerAcceptable:
+		item.Awarded = int(math.Round(float64(item.Weight) * referrerAcceptableCredit))
+		item.Issues = append(item.Issues, fmt.Sprintf("%s still sends the origin to some destinations; no-referrer or strict-origin-when-cross-origin earn full credit (%d of %d awarded)", label, item.Awarded, item.Weight))
+	case referrerWeak:
+		item.Awarded = 0
+		item.Issues = append(item.Issues, fmt.Sprintf("%s leaks full URLs to other origins; use strict-origin-when-cross-origin or no-referrer", label))
+	default:
+		item.Awarded = 0
+		item.Issues = append(item.Issues, "unknown policy is ignored by browsers; use strict-origin-when-cross-origin or no-referrer")
+	}
+}
@@ -0,0 +1,43 @@
+package internal
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestCheckReferrerPolicy(t *testing.T) {
+	tests := []struct {
+		value   string
+		awarded int
+		issue   string
+	}{
+		{"no-referrer", 16, ""},
+		{"strict-origin-when-cross-origin", 16, ""},
+		{"origin", 8, "origin still sends"},
+		{"unsafe-url", 0, "unsafe-url leaks"},
+		{"unsafe-url, no-referrer", 16, ""},
+		{"no-referrer, unsafe-url", 16, ""},
+		{"unsafe-url, same-origin", 8, "strongest token same-origin"},
+		{"no-referrer-when-downgrade, bogus", 0, "strongest token no-referrer-when-downgrade"},
+		{"bogus", 0, "unknown policy"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			header := http.Header{"Referrer-Policy": {tt.value}}
+			item := SecurityHeader{Name: "Referrer-Policy", Present: true, Weight: 16, Awarded: 16}
+			checkReferrerPolicy(&item, header)
+			if item.Awarded != tt.awarded {
+				t.Errorf("awarded %d, want %d", item.Awarded, tt.awarded)
+			}
+			issues := strings.Join(item.Issues, "; ")
+			if tt.issue == "" && issues != "" {
+				t.Errorf("unexpected issues %q", issues)
+			}
+			if !strings.Contains(issues, tt.issue) {
+				t.Errorf("issues %q do not mention %q", issues, tt.issue)
+			}
+		})
+	}
+}
@@ -0,0 +1,30 @@
+package internal
+
+import "strings"
+
+// Remediation is a ready-to-use example of a missing header, with the
+// matching directive per server type
+type Remediation struct {
+	Example string            `json:"example"`
+	Config  map[string]string `json:"config"`
+}
+
+// remediation returns the snippets adding the named header, or nil when
+// no value is recommended for it
+func remediation(name string) *Remediation {
+	value, ok := recommendedValue(name, "")
+	if !ok {
+		return nil
+	}
+
+	snippets := make(map[string]string, len(headerDirectives))
+	for server, directive := range headerDirectives {
+		line := directive(name, value)
+		if server == ServerCaddy {
+			// outside a header block, Caddy takes the single-line form
+			line = "header " + strings.TrimPrefix(line, "\t")
+		}
+		snippets[server] = line
+	}
+	return &Remediation{Example: name + ": " + value, Config: snippets}
+}
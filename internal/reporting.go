@@ -0,0 +1,145 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	neturl "net/url"
+	"strings"
+)
+
+// maxReportEndpoints caps how many CSP reporting endpoints are probed
+const maxReportEndpoints = 5
+
+// ReportEndpoint is a CSP violation reporting endpoint and whether it answered
+type ReportEndpoint struct {
+	Directive  string `json:"directive"`
+	Group      string `json:"group,omitempty"`
+	URL        string `json:"url,omitempty"`
+	Reachable  bool   `json:"reachable"`
+	StatusCode int    `json:"statusCode,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+// reportEndpoints lists the reporting endpoints a response's CSP sends
+// violation reports to. report-uri values are resolved against the page
+// URL and report-to groups are looked up in Reporting-Endpoints, falling
+// back to the legacy Report-To header.
+func reportEndpoints(pageURL string, header http.Header) []ReportEndpoint {
+	policy := parseCSP(cspValue(header))
+	endpoints := make([]ReportEndpoint, 0)
+
+	base, _ := neturl.Parse(pageURL)
+	for _, uri := range policy["report-uri"] {
+		endpoint := ReportEndpoint{Directive: "report-uri", URL: uri}
+		if ref, err := neturl.Parse(uri); err == nil && base != nil {
+			endpoint.URL = base.ResolveReference(ref).String()
+		}
+		endpoints = append(endpoints, endpoint)
+	}
+
+	if groups := policy["report-to"]; len(groups) > 0 {
+		known := reportingGroups(header)
+		for _, group := range groups {
+			endpoint := ReportEndpoint{Directive: "report-to", Group: group}
+			uri, defined := known[group]
+			if !defined {
+				endpoint.Error = fmt.Sprintf("group %q is not defined in Reporting-Endpoints or Report-To", group)
+			} else if ref, err := neturl.Parse(uri); err == nil && base != nil {
+				endpoint.URL = base.ResolveReference(ref).String()
+			} else {
+				endpoint.URL = uri
+			}
+			endpoints = append(endpoints, endpoint)
+		}
+	}
+
+	return endpoints
+}
+
+// reportingGroups maps reporting group names to their endpoint URL
+func reportingGroups(header http.Header) map[string]string {
+	groups := make(map[string]string)
+
+	// Reporting-Endpoints: default="https://r.example/csp", csp="/reports"
+	for _, member := range strings.Split(header.Get("Reporting-Endpoints"), ",") {
+		name, url, found := strings.Cut(strings.TrimSpace(member), "=")
+		if found {
+			groups[strings.TrimSpace(name)] = strings.Trim(strings.TrimSpace(url), `"`)
+		}
+	}
+
+	// Report-To: {"group":"csp","max_age":10886400,"endpoints":[{"url":"https://r.example/csp"}]}
+	var legacy []struct {
+		Group     string `json:"group"`
+		Endpoints []struct {
+			URL string `json:"url"`
+		} `json:"endpoints"`
+	}
+	if value := header.Get("Report-To"); value != "" {
+		if err := json.Unmarshal([]byte("["+value+"]"), &legacy); err == nil {
+			for _, group := range legacy {
+				name := group.Group
+				if name == "" {
+					name = "default"
+				}
+				if _, defined := groups[name]; !defined && len(group.Endpoints) > 0 {
+					groups[name] = group.Endpoints[0].URL
+				}
+			}
+		}
+	}
+
+	return groups
+}
+
+// probeReportEndpoints sends a HEAD request to each reporting endpoint. An
+// endpoint is reachable if it answers without a client or server error; a
+// 405 counts as reachable since collectors often only accept POST.
+func probeReportEndpoints(ctx context.Context, client *http.Client, endpoints []ReportEndpoint) []ReportEndpoint {
+	if len(endpoints) > maxReportEndpoints {
+		endpoints = endpoints[:maxReportEndpoints]
+	}
+
+	for i := range endpoints {
+		endpoint := &endpoints[i]
+		if endpoint.URL == "" {
+			continue
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodHead, endpoint.URL, nil)
+		if err != nil {
+			endpoint.Error = err.Error()
+			continue
+		}
+
+		resp, err := client.Do(req)
+		if err != nil {
+			endpoint.Error = err.Error()
+			continue
+		}
+		closeBody(resp)
+
+		endpoint.StatusCode = resp.StatusCode
+		endpoint.Reachable = resp.StatusCode < 400 || resp.StatusCode == http.StatusMethodNotAllowed
+	}
+
+	return endpoints
+}
+
+// markReportEndpointUnreachable flags a dead reporting endpoint on the CSP
+// summary entry, since violation reports sent there are silently lost
+func markReportEndpointUnreachable(result *AnalysisResult, endpoint ReportEndpoint) {
+	for i := range result.Summary {
+		if result.Summary[i].Name != "Content-Security-Policy" {
+			continue
+		}
+
+		issue := fmt.Sprintf("%s endpoint %s is unreachable; violation reports are lost", endpoint.Directive, endpoint.URL)
+		if endpoint.URL == "" {
+			issue = fmt.Sprintf("%s %s; violation reports are lost", endpoint.Directive, endpoint.Error)
+		}
+		result.Summary[i].Issues = append(result.Summary[i].Issues, issue)
+	}
+}
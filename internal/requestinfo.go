@@ -0,0 +1,80 @@
+package internal
+
+import (
+	"errors"
+	"net/http"
+	"net/textproto"
+	"strings"
+)
+
+// ErrUnknownMethod is returned for a request method the analyzer does not send
+var ErrUnknownMethod = errors.New("method must be one of GET, HEAD, POST, PUT, PATCH, DELETE, OPTIONS")
+
+// requestMethods are the methods an analysis may be run with
+var requestMethods = []string{
+	http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut,
+	http.MethodPatch, http.MethodDelete, http.MethodOptions,
+}
+
+// ValidMethod reports whether method can be sent by an analysis; the
+// empty method defaults to GET
+func ValidMethod(method string) bool {
+	if method == "" {
+		return true
+	}
+	for _, valid := range requestMethods {
+		if strings.EqualFold(method, valid) {
+			return true
+		}
+	}
+	return false
+}
+
+// requestMethod returns the method to send, defaulting to GET
+func requestMethod(method string) string {
+	if method == "" {
+		return http.MethodGet
+	}
+	return strings.ToUpper(method)
+}
+
+// setRequestHeaders adds caller-supplied headers to req. A Host header
+// overrides the host sent instead of being ignored.
+func setRequestHeaders(req *http.Request, headers map[string]string) {
+	for name, value := range headers {
+		if strings.EqualFold(name, "Host") {
+			req.Host = value
+			continue
+		}
+		req.Header.Set(name, value)
+	}
+}
+
+// RequestInfo describes the request the analyzer actually sent
+type RequestInfo struct {
+	Method  string              `json:"method"`
+	URL     string              `json:"url"`
+	Scheme  string              `json:"scheme"`
+	Headers map[string][]string `json:"headers"`
+}
+
+// writtenHeaders records the header fields written to the wire for a request
+type writtenHeaders http.Header
+
+// add is used as the httptrace WroteHeaderField hook
+func (w writtenHeaders) add(key string, values []string) {
+	key = textproto.CanonicalMIMEHeaderKey(key)
+	w[key] = append(w[key], values...)
+}
+
+// requestInfo describes the request behind resp using the headers that
+// were written for it, redacting sensitive values
+func requestInfo(resp *http.Response, written writtenHeaders) *RequestInfo {
+	req := resp.Request
+	return &RequestInfo{
+		Method:  req.Method,
+		URL:     req.URL.String(),
+		Scheme:  req.URL.Scheme,
+		Headers: collectHeaders(http.Header(written)),
+	}
+}
@@ -0,0 +1,158 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"net"
+	neturl "net/url"
+	"sync"
+)
+
+// MaxResolvers caps how many resolvers a single analysis may fan out to
+const MaxResolvers = 5
+
+// ResolverResult is the analysis of a URL fetched from the address a
+// specific DNS resolver returned
+type ResolverResult struct {
+	Resolver    string          `json:"resolver"`
+	ResolvedIPs []string        `json:"resolvedIps,omitempty"`
+	ConnectedIP string          `json:"connectedIp,omitempty"`
+	Score       int             `json:"score"`
+	Grade       string          `json:"grade,omitempty"`
+	Headers     map[string]bool `json:"headers,omitempty"`
+	Error       string          `json:"error,omitempty"`
+}
+
+// resolverAddress adds the default DNS port to a resolver given as a bare IP
+func resolverAddress(resolver string) string {
+	if _, _, err := net.SplitHostPort(resolver); err == nil {
+		return resolver
+	}
+	return net.JoinHostPort(resolver, "53")
+}
+
+// analyzeThroughResolvers resolves the URL's host through each resolver and
+// analyzes the response served from the first address it returned
+func analyzeThroughResolvers(ctx context.Context, url string, headers []SecurityHeader, opts Options) []ResolverResult {
+	results := make([]ResolverResult, len(opts.Resolvers))
+
+	var wg sync.WaitGroup
+	for i, resolver := range opts.Resolvers {
+		wg.Add(1)
+		go func(i int, resolver string) {
+			defer wg.Done()
+			results[i] = analyzeThroughResolver(ctx, url, headers, resolverAddress(resolver), opts)
+		}(i, resolver)
+	}
+	wg.Wait()
+
+	return results
+}
+
+// analyzeThroughResolver performs a single per-resolver analysis. The request
+// is the one the main analysis sends, with the same method, headers,
+// redirect handling and scoring profile; only the address connected to
+// differs.
+func analyzeThroughResolver(ctx context.Context, url string, headers []SecurityHeader, resolver string, opts Options) ResolverResult {
+	result := ResolverResult{Resolver: resolver}
+
+	target, err := neturl.Parse(url)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	dns := &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			return newDialer().DialContext(ctx, network, resolver)
+		},
+	}
+
+	addrs, err := dns.LookupIPAddr(ctx, target.Hostname())
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	if len(addrs) == 0 {
+		result.Error = fmt.Sprintf("resolver returned no addresses for %s", target.Hostname())
+		return result
+	}
+	for _, addr := range addrs {
+		result.ResolvedIPs = append(result.ResolvedIPs, addr.IP.String())
+	}
+	result.ConnectedIP = result.ResolvedIPs[0]
+
+	// Pin connections to the URL's host to the resolved address while
+	// keeping the host for the Host header and TLS server name. Redirects
+	// to other hosts resolve normally.
+	client := newClient(newTransport(func(ctx context.Context, network, addr string) (net.Conn, error) {
+		host, port, err := net.SplitHostPort(addr)
+		if err != nil {
+			return nil, err
+		}
+		if host == target.Hostname() {
+			addr = net.JoinHostPort(result.ConnectedIP, port)
+		}
+		return newDialer().DialContext(ctx, network, addr)
+	}, opts.RequireValidCertificate), opts.Timeout)
+	defer client.CloseIdleConnections()
+
+	req, err := newAnalysisRequest(ctx, url, opts)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	fetcher := client
+	if opts.FollowRedirects {
+		fetcher = followRedirects(client, &RedirectChain{}, func() {})
+	}
+
+	resp, err := fetcher.Do(req)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	defer resp.Body.Close()
+	readBody(resp)
+
+	scored := scoreResponse(resp.Request.URL.String(), headers, resp, opts.Authenticated)
+	var upgrade *UpgradeResult
+	if normalizeProfile(opts.Profile) == ProfileMozilla {
+		upgrade = probeUpgrade(ctx, client, url)
+	}
+	applyProfile(scored, scoringInput{result: scored, header: resp.Header, upgrade: upgrade}, opts.Profile)
+
+	result.Score = scored.Score
+	result.Grade = scored.Grade
+	result.Headers = scored.Headers
+
+	return result
+}
+
+// resolverDifferences describes where per-resolver analyses deviate from
+// the analysis through the default resolver
+func resolverDifferences(base *AnalysisResult, results []ResolverResult) []string {
+	var differences []string
+	for _, result := range results {
+		if result.Error != "" {
+			continue
+		}
+
+		label := fmt.Sprintf("resolver %s (%s)", result.Resolver, result.ConnectedIP)
+		if result.Score != base.Score {
+			differences = append(differences, fmt.Sprintf("%s: score %d differs from %d through the default resolver", label, result.Score, base.Score))
+		}
+		for _, header := range base.Summary {
+			present := result.Headers[header.Name]
+			switch {
+			case header.Present && !present:
+				differences = append(differences, fmt.Sprintf("%s: %s is missing but present through the default resolver", label, header.Name))
+			case !header.Present && present:
+				differences = append(differences, fmt.Sprintf("%s: %s is present but missing through the default resolver", label, header.Name))
+			}
+		}
+	}
+	return differences
+}
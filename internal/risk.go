@@ -0,0 +1,67 @@
+package internal
+
+import (
+	"net/http"
+	"strings"
+)
+
+// Plain-language risk levels reported alongside the grade
+const (
+	RiskLow      = "Low"
+	RiskModerate = "Moderate"
+	RiskHigh     = "High risk"
+)
+
+// gradeRisk is the baseline risk level for each grade letter; the + and -
+// modifiers of the Mozilla profile share the level of their letter
+var gradeRisk = map[string]string{
+	"A": RiskLow,
+	"B": RiskModerate,
+	"C": RiskModerate,
+	"D": RiskHigh,
+	"F": RiskHigh,
+}
+
+// riskEscalation is a finding that makes a site high risk regardless of grade
+type riskEscalation struct {
+	reason  string
+	matches func(result *AnalysisResult, header http.Header) bool
+}
+
+var riskEscalations = []riskEscalation{
+	{
+		reason: "site is not served over HTTPS",
+		matches: func(result *AnalysisResult, header http.Header) bool {
+			return !result.servedOverHTTPS()
+		},
+	},
+	{
+		reason: "CORS allows any origin with credentials",
+		matches: func(result *AnalysisResult, header http.Header) bool {
+			origin := header.Get("Access-Control-Allow-Origin")
+			return (origin == "*" || origin == "null") &&
+				strings.EqualFold(header.Get("Access-Control-Allow-Credentials"), "true")
+		},
+	},
+}
+
+// classifyRisk derives the plain-language risk level of a graded result
+// and the high-severity findings that escalated it
+func classifyRisk(result *AnalysisResult, header http.Header) (string, []string) {
+	level, ok := gradeRisk[strings.TrimRight(result.Grade, "+-")]
+	if !ok {
+		level = RiskHigh
+	}
+
+	var reasons []string
+	for _, escalation := range riskEscalations {
+		if escalation.matches(result, header) {
+			reasons = append(reasons, escalation.reason)
+		}
+	}
+	if len(reasons) > 0 {
+		level = RiskHigh
+	}
+
+	return level, reasons
+}
@@ -0,0 +1,62 @@
+package internal
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// Scheduler periodically rescans an inventory of URLs
+type Scheduler struct {
+	URLs        []string
+	Interval    time.Duration
+	Concurrency int
+
+	// Limiter, when set, paces the analyses of a scan
+	Limiter *RateLimiter
+
+	// OnResult is called for every successfully analyzed URL
+	OnResult func(result *AnalysisResult)
+}
+
+// Run scans the inventory immediately and then once per interval until ctx
+// is done. A scan still running when the next one is due is cut short, so
+// scans never overlap.
+func (s *Scheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.Interval)
+	defer ticker.Stop()
+
+	for {
+		s.scan(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// scan analyzes the whole inventory once
+func (s *Scheduler) scan(ctx context.Context) {
+	scanCtx, cancel := context.WithTimeout(ctx, s.Interval)
+	defer cancel()
+
+	started := time.Now()
+	report := analyzeBatch(scanCtx, s.URLs, s.Concurrency, s.Limiter, nil)
+
+	failed := 0
+	for _, item := range report.Items {
+		if item.Result == nil {
+			failed++
+			log.Printf("Scheduled scan of %s failed: %s", item.URL, item.Error)
+			continue
+		}
+		if s.OnResult != nil {
+			s.OnResult(item.Result)
+		}
+	}
+
+	log.Printf("Scheduled scan of %d URLs finished in %s (%d failed, %d skipped)",
+		len(s.URLs), time.Since(started).Round(time.Millisecond), failed, report.Skipped)
+}
@@ -0,0 +1,117 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+// SchemeDiscrepancies compares the checked headers served over HTTP and
+// HTTPS. A site with strong headers on HTTPS that still serves the HTTP
+// version without them, and without redirecting, remains exploitable.
+type SchemeDiscrepancies struct {
+	HTTPStatus       int                 `json:"httpStatus,omitempty"`
+	HTTPSStatus      int                 `json:"httpsStatus,omitempty"`
+	RedirectsToHTTPS bool                `json:"redirectsToHttps"`
+	Headers          []SchemeDiscrepancy `json:"headers"`
+	Error            string              `json:"error,omitempty"`
+}
+
+// SchemeDiscrepancy is a checked header that is sent over one scheme but
+// not the other, or with a different value
+type SchemeDiscrepancy struct {
+	Header     string   `json:"header"`
+	HTTPS      bool     `json:"https"`
+	HTTP       bool     `json:"http"`
+	HTTPSValue string   `json:"httpsValue,omitempty"`
+	HTTPValue  string   `json:"httpValue,omitempty"`
+	Severity   Severity `json:"severity"`
+	Issue      string   `json:"issue"`
+}
+
+// compareSchemes requests url over both HTTP and HTTPS and lists the
+// checked headers that differ. Nothing is compared when HTTP redirects to
+// HTTPS, since browsers never render the HTTP response then.
+// Strict-Transport-Security is skipped, as browsers ignore it over HTTP.
+func compareSchemes(ctx context.Context, client *http.Client, url string, headers []SecurityHeader) *SchemeDiscrepancies {
+	comparison := &SchemeDiscrepancies{Headers: make([]SchemeDiscrepancy, 0)}
+
+	secure, err := fetchScheme(ctx, client, url, "https")
+	if err != nil {
+		comparison.Error = fmt.Sprintf("HTTPS: %v", err)
+		return comparison
+	}
+	comparison.HTTPSStatus = secure.StatusCode
+
+	plain, err := fetchScheme(ctx, client, url, "http")
+	if err != nil {
+		comparison.Error = fmt.Sprintf("HTTP: %v", err)
+		return comparison
+	}
+	comparison.HTTPStatus = plain.StatusCode
+
+	if redirectsToHTTPS(plain) {
+		comparison.RedirectsToHTTPS = true
+		return comparison
+	}
+
+	for _, header := range headers {
+		if header.Name == "Strict-Transport-Security" {
+			continue
+		}
+		if discrepancy, ok := schemeDiscrepancy(header, secure, plain); ok {
+			comparison.Headers = append(comparison.Headers, discrepancy)
+		}
+	}
+	return comparison
+}
+
+// redirectsToHTTPS reports whether resp redirects to an https:// URL
+func redirectsToHTTPS(resp *http.Response) bool {
+	if resp.StatusCode < 300 || resp.StatusCode >= 400 {
+		return false
+	}
+	location, err := resp.Request.URL.Parse(resp.Header.Get("Location"))
+	return err == nil && location.Scheme == "https"
+}
+
+// schemeDiscrepancy compares header between the HTTPS and HTTP responses.
+// A header missing over HTTP is as severe as the header's tier warrants;
+// one missing over HTTPS only, or sent with another value, is low.
+func schemeDiscrepancy(header SecurityHeader, secure, plain *http.Response) (SchemeDiscrepancy, bool) {
+	secureValue, _ := headerValue(secure, header)
+	plainValue, _ := headerValue(plain, header)
+	discrepancy := SchemeDiscrepancy{
+		Header:     header.Name,
+		HTTPS:      secureValue != "",
+		HTTP:       plainValue != "",
+		HTTPSValue: secureValue,
+		HTTPValue:  plainValue,
+		Severity:   SeverityLow,
+	}
+
+	switch {
+	case secureValue == plainValue:
+		return discrepancy, false
+	case plainValue == "":
+		discrepancy.Severity = schemeSeverity(header.tier())
+		discrepancy.Issue = fmt.Sprintf("%s is sent over HTTPS but not over HTTP, which is served without redirecting", header.Name)
+	case secureValue == "":
+		discrepancy.Issue = fmt.Sprintf("%s is sent over HTTP but not over HTTPS", header.Name)
+	default:
+		discrepancy.Issue = fmt.Sprintf("%s has a different value over HTTP than over HTTPS", header.Name)
+	}
+	return discrepancy, true
+}
+
+// schemeSeverity is the severity of a header missing over plain HTTP
+func schemeSeverity(tier SecurityHeaderTier) Severity {
+	switch tier {
+	case Critical:
+		return SeverityHigh
+	case Important:
+		return SeverityMedium
+	default:
+		return SeverityLow
+	}
+}
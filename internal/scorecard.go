@@ -0,0 +1,116 @@
+package internal
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// maxScorecardGaps caps how many systemic gaps a scorecard lists
+const maxScorecardGaps = 5
+
+// PeriodSummary aggregates the latest analysis of every URL in a period
+type PeriodSummary struct {
+	From         time.Time      `json:"from"`
+	To           time.Time      `json:"to"`
+	URLs         int            `json:"urls"`
+	AverageScore float64        `json:"averageScore"`
+	AverageGrade string         `json:"averageGrade,omitempty"`
+	GradeCounts  map[string]int `json:"gradeCounts"`
+}
+
+// ScorecardGap is a header missing across many URLs
+type ScorecardGap struct {
+	Header string `json:"header"`
+	URLs   int    `json:"urls"`
+}
+
+// Scorecard is the organization-wide summary of a reporting period compared
+// with the period before it
+type Scorecard struct {
+	Current    PeriodSummary  `json:"current"`
+	Previous   PeriodSummary  `json:"previous"`
+	ScoreTrend float64        `json:"scoreTrend"`
+	TopGaps    []ScorecardGap `json:"topGaps"`
+}
+
+// Scorecard summarizes the period ending at now, comparing it with the
+// period of the same length before it
+func (h *History) Scorecard(period time.Duration, now time.Time) *Scorecard {
+	currentStart := now.Add(-period)
+	current := latestPerURL(h.Entries(currentStart, now))
+	previous := latestPerURL(h.Entries(currentStart.Add(-period), currentStart))
+
+	card := &Scorecard{
+		Current:  summarizePeriod(current, currentStart, now),
+		Previous: summarizePeriod(previous, currentStart.Add(-period), currentStart),
+		TopGaps:  topGaps(current),
+	}
+	if card.Previous.URLs > 0 && card.Current.URLs > 0 {
+		card.ScoreTrend = roundTenth(card.Current.AverageScore - card.Previous.AverageScore)
+	}
+	return card
+}
+
+// latestPerURL keeps the most recent of the given entries for each URL
+func latestPerURL(entries []HistoryEntry) map[string]HistoryEntry {
+	latest := make(map[string]HistoryEntry)
+	for _, entry := range entries {
+		if existing, ok := latest[entry.URL]; !ok || !entry.RecordedAt.Before(existing.RecordedAt) {
+			latest[entry.URL] = entry
+		}
+	}
+	return latest
+}
+
+// summarizePeriod averages the scores and counts the grades of the entries
+func summarizePeriod(entries map[string]HistoryEntry, from, to time.Time) PeriodSummary {
+	summary := PeriodSummary{
+		From:        from,
+		To:          to,
+		URLs:        len(entries),
+		GradeCounts: make(map[string]int),
+	}
+	if len(entries) == 0 {
+		return summary
+	}
+
+	total := 0
+	for _, entry := range entries {
+		total += entry.Score
+		summary.GradeCounts[entry.Grade]++
+	}
+	summary.AverageScore = roundTenth(float64(total) / float64(len(entries)))
+	summary.AverageGrade = calculateGrade(int(math.Round(summary.AverageScore)))
+	return summary
+}
+
+// topGaps returns the headers missing on the most URLs, most common first
+func topGaps(entries map[string]HistoryEntry) []ScorecardGap {
+	counts := make(map[string]int)
+	for _, entry := range entries {
+		for _, name := range entry.Missing {
+			counts[name]++
+		}
+	}
+
+	gaps := make([]ScorecardGap, 0, len(counts))
+	for name, count := range counts {
+		gaps = append(gaps, ScorecardGap{Header: name, URLs: count})
+	}
+	sort.Slice(gaps, func(i, j int) bool {
+		if gaps[i].URLs != gaps[j].URLs {
+			return gaps[i].URLs > gaps[j].URLs
+		}
+		return gaps[i].Header < gaps[j].Header
+	})
+	if len(gaps) > maxScorecardGaps {
+		gaps = gaps[:maxScorecardGaps]
+	}
+	return gaps
+}
+
+// roundTenth rounds v to one decimal place
+func roundTenth(v float64) float64 {
+	return math.Round(v*10) / 10
+}
@@ -0,0 +1,18 @@
+package internal
+
+import (
+	"net/http"
+	"strings"
+)
+
+// checkSetLogin awards no credit for a Set-Login value other than the
+// logged-in and logged-out statuses FedCM understands
+func checkSetLogin(item *SecurityHeader, header http.Header) {
+	value := strings.ToLower(strings.TrimSpace(header.Get("Set-Login")))
+	if value == "logged-in" || value == "logged-out" {
+		return
+	}
+
+	item.Awarded = 0
+	item.Issues = append(item.Issues, "unknown login status; expected logged-in or logged-out")
+}
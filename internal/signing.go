@@ -0,0 +1,68 @@
+package internal
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+// signaturePrefix names the algorithm of a result signature
+const signaturePrefix = "hmac-sha256:"
+
+// ErrSigningDisabled is returned when no signing secret is configured
+var ErrSigningDisabled = errors.New("result signing is not configured")
+
+// resultMAC computes the HMAC of the JSON serialization of a result,
+// leaving out its signature
+func resultMAC(result *AnalysisResult) ([]byte, error) {
+	unsigned := *result
+	unsigned.Signature = ""
+
+	payload, err := json.Marshal(&unsigned)
+	if err != nil {
+		return nil, err
+	}
+
+	mac := hmac.New(sha256.New, []byte(config.SigningSecret))
+	mac.Write(payload)
+	return mac.Sum(nil), nil
+}
+
+// SignResult sets the signature of result with the configured secret
+func SignResult(result *AnalysisResult) error {
+	if config.SigningSecret == "" {
+		return ErrSigningDisabled
+	}
+
+	sum, err := resultMAC(result)
+	if err != nil {
+		return err
+	}
+	result.Signature = signaturePrefix + hex.EncodeToString(sum)
+	return nil
+}
+
+// VerifyResult reports whether the signature of result matches its content
+func VerifyResult(result *AnalysisResult) (bool, error) {
+	if config.SigningSecret == "" {
+		return false, ErrSigningDisabled
+	}
+
+	encoded, ok := strings.CutPrefix(result.Signature, signaturePrefix)
+	if !ok {
+		return false, nil
+	}
+	signature, err := hex.DecodeString(encoded)
+	if err != nil {
+		return false, nil
+	}
+
+	sum, err := resultMAC(result)
+	if err != nil {
+		return false, err
+	}
+	return hmac.Equal(signature, sum), nil
+}
@@ -0,0 +1,62 @@
+package internal
+
+import "errors"
+
+// SiteType selects a weight preset tuned for a kind of site
+type SiteType string
+
+const (
+	// SiteTypeBalanced keeps the default weights
+	SiteTypeBalanced SiteType = ""
+	// SiteTypeApp emphasizes the headers protecting interactive pages
+	SiteTypeApp SiteType = "app"
+	// SiteTypeAPI emphasizes transport and cross-origin resource protection
+	SiteTypeAPI SiteType = "api"
+	// SiteTypeStatic emphasizes transport security over page hardening
+	SiteTypeStatic SiteType = "static"
+)
+
+// ErrUnknownSiteType is returned for a site type without a weight preset
+var ErrUnknownSiteType = errors.New("siteType must be one of app, api, static")
+
+// siteTypeWeights overrides header weights per site type; headers not
+// listed keep their default weight
+var siteTypeWeights = map[SiteType]map[string]int{
+	SiteTypeApp: {
+		"Content-Security-Policy":    25,
+		"X-Frame-Options":            20,
+		"Permissions-Policy":         12,
+		"Cross-Origin-Opener-Policy": 10,
+	},
+	SiteTypeAPI: {
+		"Strict-Transport-Security":    25,
+		"X-Content-Type-Options":       20,
+		"Cross-Origin-Resource-Policy": 15,
+		"X-Frame-Options":              5,
+		"Content-Security-Policy":      5,
+		"Permissions-Policy":           3,
+		"Cross-Origin-Opener-Policy":   3,
+	},
+	SiteTypeStatic: {
+		"Strict-Transport-Security":    25,
+		"Content-Security-Policy":      10,
+		"Referrer-Policy":              10,
+		"Cross-Origin-Resource-Policy": 10,
+		"Permissions-Policy":           5,
+		"Cross-Origin-Opener-Policy":   5,
+	},
+}
+
+// ValidSiteType reports whether s has a weight preset
+func ValidSiteType(s SiteType) bool {
+	if s == SiteTypeBalanced {
+		return true
+	}
+	_, ok := siteTypeWeights[s]
+	return ok
+}
+
+// applySiteType returns copies of headers weighted for the site type
+func applySiteType(headers []SecurityHeader, siteType SiteType) []SecurityHeader {
+	return applyWeights(headers, siteTypeWeights[siteType])
+}
@@ -0,0 +1,46 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"syscall"
+)
+
+// ErrBlockedAddress is returned when a target resolves to an internal
+// address the analyzer refuses to connect to
+var ErrBlockedAddress = errors.New("target address is private, loopback or link-local")
+
+// carrierGradeNAT is the shared address space (RFC 6598), which also hosts
+// some cloud metadata endpoints
+var carrierGradeNAT = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+// addressAllowed reports whether the analyzer may connect to ip. Private,
+// loopback, link-local (including the 169.254.169.254 metadata endpoint),
+// unspecified and multicast addresses are refused unless they fall inside
+// one of the configured allowed networks.
+func addressAllowed(ip net.IP) bool {
+	for _, network := range config.AllowedNetworks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return !ip.IsLoopback() && !ip.IsPrivate() && !ip.IsUnspecified() &&
+		!ip.IsLinkLocalUnicast() && !ip.IsLinkLocalMulticast() &&
+		!ip.IsInterfaceLocalMulticast() && !ip.IsMulticast() &&
+		!carrierGradeNAT.Contains(ip)
+}
+
+// guardAddress is the dialer Control hook refusing blocked addresses. It
+// sees the resolved address each connection is made to, so a host name
+// re-resolving to an internal address (DNS rebinding) is refused too.
+func guardAddress(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if ip := net.ParseIP(host); ip == nil || !addressAllowed(ip) {
+		return fmt.Errorf("%w: %s", ErrBlockedAddress, host)
+	}
+	return nil
+}
@@ -0,0 +1,28 @@
+package internal
+
+import "fmt"
+
+// StatusError is returned when a successful response is required and the
+// target answered with another status
+type StatusError struct {
+	URL        string
+	StatusCode int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("%s responded with status %d", e.URL, e.StatusCode)
+}
+
+// successStatus reports whether code is a 2xx status
+func successStatus(code int) bool {
+	return code >= 200 && code < 300
+}
+
+// statusWarning explains why the headers of a response with the given
+// non-2xx status may not represent the real pages of the site
+func statusWarning(code int) string {
+	if code >= 300 && code < 400 {
+		return fmt.Sprintf("status %d is a redirect, whose headers often differ from the page it points to; use followRedirects to score the final page", code)
+	}
+	return fmt.Sprintf("status %d is an error response; error pages often lack the security headers real pages set, so the score may be misleading", code)
+}
@@ -0,0 +1,90 @@
+package internal
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// DefaultResultStoreSize is how many URLs the result store remembers by default
+const DefaultResultStoreSize = 500
+
+// StoredResult is the latest analysis of a URL held in a ResultStore
+type StoredResult struct {
+	URL        string          `json:"url"`
+	Grade      string          `json:"grade"`
+	Score      int             `json:"score"`
+	RecordedAt time.Time       `json:"recordedAt"`
+	Result     *AnalysisResult `json:"result"`
+}
+
+// ResultStore is a bounded, concurrency-safe store of the most recent
+// result for each analyzed URL. When full, the least recently updated URL
+// is evicted.
+type ResultStore struct {
+	mu      sync.RWMutex
+	limit   int
+	order   *list.List // of *StoredResult, most recent first
+	entries map[string]*list.Element
+}
+
+// NewResultStore creates a store remembering at most limit URLs
+func NewResultStore(limit int) *ResultStore {
+	return &ResultStore{
+		limit:   limit,
+		order:   list.New(),
+		entries: make(map[string]*list.Element),
+	}
+}
+
+// Record stores result as the latest analysis of its URL.
+// Recording into a nil store is a no-op.
+func (s *ResultStore) Record(result *AnalysisResult) {
+	if s == nil || result == nil {
+		return
+	}
+
+	entry := &StoredResult{
+		URL:        result.URL,
+		Grade:      result.Grade,
+		Score:      result.Score,
+		RecordedAt: time.Now().UTC(),
+		Result:     result,
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if element, ok := s.entries[result.URL]; ok {
+		element.Value = entry
+		s.order.MoveToFront(element)
+		return
+	}
+
+	s.entries[result.URL] = s.order.PushFront(entry)
+	for s.order.Len() > s.limit {
+		oldest := s.order.Back()
+		s.order.Remove(oldest)
+		delete(s.entries, oldest.Value.(*StoredResult).URL)
+	}
+}
+
+// Query returns the stored results, most recent first, optionally
+// restricted to a single grade. A limit of 0 returns every match.
+func (s *ResultStore) Query(grade string, limit int) []StoredResult {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	results := make([]StoredResult, 0)
+	for element := s.order.Front(); element != nil; element = element.Next() {
+		entry := element.Value.(*StoredResult)
+		if grade != "" && entry.Grade != grade {
+			continue
+		}
+		results = append(results, *entry)
+		if limit > 0 && len(results) == limit {
+			break
+		}
+	}
+	return results
+}
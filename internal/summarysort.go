@@ -0,0 +1,43 @@
+package internal
+
+import (
+	"errors"
+	"sort"
+)
+
+// Summary orderings; the zero value keeps the definition order
+const (
+	SortByWeight = "weight"
+	SortByName   = "name"
+	SortByTier   = "tier"
+)
+
+// ErrUnknownSort is returned for a summary ordering that is not supported
+var ErrUnknownSort = errors.New("sort must be one of weight, name, tier")
+
+// ValidSort reports whether by is a supported summary ordering
+func ValidSort(by string) bool {
+	switch by {
+	case "", SortByWeight, SortByName, SortByTier:
+		return true
+	}
+	return false
+}
+
+// sortSummary orders the summary entries in place. Ties keep their
+// definition order.
+func sortSummary(summary []SecurityHeader, by string) {
+	var less func(a, b SecurityHeader) bool
+	switch by {
+	case SortByWeight:
+		less = func(a, b SecurityHeader) bool { return a.Weight > b.Weight }
+	case SortByName:
+		less = func(a, b SecurityHeader) bool { return a.Name < b.Name }
+	case SortByTier:
+		less = func(a, b SecurityHeader) bool { return a.tier() < b.tier() }
+	default:
+		return
+	}
+
+	sort.SliceStable(summary, func(i, j int) bool { return less(summary[i], summary[j]) })
+}
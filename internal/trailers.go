@@ -0,0 +1,40 @@
+package internal
+
+import (
+	"io"
+	"net/http"
+)
+
+// maxPageBody caps how much of a response body is read
+const maxPageBody = 1 << 20
+
+// maxDrainBody caps how much of an unread response body is discarded so
+// the connection can be reused; a longer body closes the connection instead
+const maxDrainBody = 64 << 10
+
+// SourceTrailer marks a security header that was delivered as an HTTP trailer
+const SourceTrailer = "trailer"
+
+// readBody reads up to maxPageBody of the response body, decoded according
+// to its Content-Encoding. Trailers are only populated once the body has
+// been read to the end, so a body larger than the cap leaves them unread.
+func readBody(resp *http.Response) []byte {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxPageBody))
+	return decodeBody(body, resp.Header.Get("Content-Encoding"))
+}
+
+// closeBody drains up to maxDrainBody of a body that is not needed and
+// closes it, so the kept-alive connection is returned for reuse
+func closeBody(resp *http.Response) {
+	io.Copy(io.Discard, io.LimitReader(resp.Body, maxDrainBody))
+	resp.Body.Close()
+}
+
+// trailerResponse returns a response whose headers are the trailers of resp,
+// or nil when it has none
+func trailerResponse(resp *http.Response) *http.Response {
+	if len(resp.Trailer) == 0 {
+		return nil
+	}
+	return &http.Response{Header: resp.Trailer}
+}
@@ -0,0 +1,95 @@
+package internal
+
+import (
+	"context"
+	"net/http"
+	neturl "net/url"
+	"slices"
+)
+
+// TransportSecurity summarizes how the host is served over plain HTTP and
+// HTTPS, and whether browsers are kept from being downgraded to HTTP
+type TransportSecurity struct {
+	HTTPS            bool     `json:"https"`
+	HTTPSError       string   `json:"httpsError,omitempty"`
+	HTTP             bool     `json:"http"`
+	HTTPError        string   `json:"httpError,omitempty"`
+	RedirectsToHTTPS bool     `json:"redirectsToHttps"`
+	HSTS             bool     `json:"hsts"`
+	Issues           []string `json:"issues,omitempty"`
+}
+
+// probeTransport requests both the https:// and the http:// form of url.
+// The HTTP side reuses the upgrade probe; an explicit port is dropped when
+// switching schemes, since it serves the other protocol.
+func probeTransport(ctx context.Context, client *http.Client, url string) *TransportSecurity {
+	transport := &TransportSecurity{}
+
+	upgrade := probeUpgrade(ctx, client, url)
+	transport.HTTP = len(upgrade.Hops) > 0
+	transport.HTTPError = upgrade.Error
+	transport.RedirectsToHTTPS = upgrade.Upgraded
+
+	header, err := fetchHTTPS(ctx, client, url)
+	if err != nil {
+		transport.HTTPSError = err.Error()
+	} else {
+		transport.HTTPS = true
+		maxAges := hstsMaxAges(header.Get("Strict-Transport-Security"))
+		transport.HSTS = len(maxAges) > 0 && slices.Min(maxAges) > 0
+	}
+
+	transport.Issues = transportIssues(transport)
+	return transport
+}
+
+// fetchHTTPS requests the https:// form of url and returns the response
+// headers
+func fetchHTTPS(ctx context.Context, client *http.Client, url string) (http.Header, error) {
+	resp, err := fetchScheme(ctx, client, url, "https")
+	if err != nil {
+		return nil, err
+	}
+	return resp.Header, nil
+}
+
+// fetchScheme requests url over scheme and returns the response with its
+// body closed. An explicit port is dropped when switching schemes, since
+// it serves the other protocol.
+func fetchScheme(ctx context.Context, client *http.Client, url, scheme string) (*http.Response, error) {
+	target, err := neturl.Parse(url)
+	if err != nil {
+		return nil, err
+	}
+	if target.Scheme != scheme {
+		target.Host = hostWithoutPort(target)
+	}
+	target.Scheme = scheme
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	closeBody(resp)
+	return resp, nil
+}
+
+// transportIssues flags a missing HTTPS endpoint, plain HTTP that is not
+// redirected and HTTPS without HSTS
+func transportIssues(transport *TransportSecurity) []string {
+	var issues []string
+	if !transport.HTTPS {
+		issues = append(issues, "HTTPS is not served; all traffic can be read and modified in transit")
+	}
+	if transport.HTTP && !transport.RedirectsToHTTPS {
+		issues = append(issues, "plain HTTP is served without redirecting to HTTPS")
+	}
+	if transport.HTTPS && !transport.HSTS {
+		issues = append(issues, "HTTPS does not send Strict-Transport-Security with a max-age above 0, so browsers can be downgraded to plain HTTP")
+	}
+	return issues
+}
@@ -0,0 +1,47 @@
+package internal
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// securityHeaderHints are name fragments of headers that look security
+// relevant
+var securityHeaderHints = []string{
+	"security", "policy", "cross-origin", "xss", "frame", "isolation",
+	"integrity", "permitted", "clear-site-data", "origin-agent-cluster",
+	"expect-", "download-options", "dns-prefetch-control",
+}
+
+// unrecognizedHeaders lists the response headers that look security
+// relevant but that the analyzer neither scores nor reports elsewhere,
+// sorted by name, so custom and emerging headers are easy to spot
+func unrecognizedHeaders(header http.Header, headers []SecurityHeader) []string {
+	known := make(map[string]bool)
+	for _, checked := range headers {
+		known[http.CanonicalHeaderKey(checked.Name)] = true
+		for _, alias := range checked.Aliases {
+			known[http.CanonicalHeaderKey(alias)] = true
+		}
+	}
+	for _, deprecated := range deprecatedHeaders {
+		known[http.CanonicalHeaderKey(deprecated.name)] = true
+	}
+
+	var names []string
+	for name := range header {
+		lower := strings.ToLower(name)
+		if known[http.CanonicalHeaderKey(name)] || strings.HasPrefix(lower, "access-control-") {
+			continue
+		}
+		for _, hint := range securityHeaderHints {
+			if strings.Contains(lower, hint) {
+				names = append(names, name)
+				break
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
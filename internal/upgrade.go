@@ -0,0 +1,133 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	neturl "net/url"
+	"time"
+)
+
+const (
+	// maxUpgradeHops caps how many redirects are followed looking for HTTPS
+	maxUpgradeHops = 5
+	// slowUpgrade is the total redirect time above which an upgrade is slow
+	slowUpgrade = time.Second
+)
+
+// UpgradeHop is a single response on the way from HTTP to HTTPS
+type UpgradeHop struct {
+	URL        string `json:"url"`
+	StatusCode int    `json:"statusCode"`
+	Location   string `json:"location,omitempty"`
+	DurationMs int64  `json:"durationMs"`
+}
+
+// UpgradeResult describes how a plain HTTP request is upgraded to HTTPS
+type UpgradeResult struct {
+	Start     string       `json:"start"`
+	Hops      []UpgradeHop `json:"hops"`
+	Upgraded  bool         `json:"upgraded"`
+	FinalURL  string       `json:"finalUrl,omitempty"`
+	Permanent bool         `json:"permanent"`
+	SameHost  bool         `json:"sameHost"`
+	TotalMs   int64        `json:"totalMs"`
+	Issues    []string     `json:"issues,omitempty"`
+	Error     string       `json:"error,omitempty"`
+}
+
+// permanentRedirect reports whether status is a permanent redirect
+func permanentRedirect(status int) bool {
+	return status == http.StatusMovedPermanently || status == http.StatusPermanentRedirect
+}
+
+// probeUpgrade requests the http:// form of url and follows redirects until
+// an HTTPS URL is reached, timing every hop. An explicit port is dropped,
+// since it serves TLS, so the probe goes to the default HTTP port.
+func probeUpgrade(ctx context.Context, client *http.Client, url string) *UpgradeResult {
+	start, err := neturl.Parse(url)
+	if err != nil {
+		return &UpgradeResult{Start: url, Error: err.Error()}
+	}
+	if start.Scheme == "https" {
+		start.Host = hostWithoutPort(start)
+	}
+	start.Scheme = "http"
+
+	result := &UpgradeResult{Start: start.String(), Hops: make([]UpgradeHop, 0), Permanent: true}
+	current := start
+	for len(result.Hops) < maxUpgradeHops {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, current.String(), nil)
+		if err != nil {
+			result.Error = err.Error()
+			return result
+		}
+
+		began := time.Now()
+		resp, err := client.Do(req)
+		if err != nil {
+			result.Error = err.Error()
+			return result
+		}
+		closeBody(resp)
+		elapsed := time.Since(began)
+
+		hop := UpgradeHop{
+			URL:        current.String(),
+			StatusCode: resp.StatusCode,
+			Location:   resp.Header.Get("Location"),
+			DurationMs: elapsed.Milliseconds(),
+		}
+		result.Hops = append(result.Hops, hop)
+		result.TotalMs += hop.DurationMs
+
+		if hop.Location == "" || resp.StatusCode < 300 || resp.StatusCode >= 400 {
+			break
+		}
+		if !permanentRedirect(resp.StatusCode) {
+			result.Permanent = false
+		}
+
+		next, err := current.Parse(hop.Location)
+		if err != nil {
+			result.Error = err.Error()
+			return result
+		}
+		current = next
+		if current.Scheme == "https" {
+			result.Upgraded = true
+			result.FinalURL = current.String()
+			result.SameHost = current.Hostname() == start.Hostname()
+			break
+		}
+	}
+
+	if !result.Upgraded {
+		result.Permanent = false
+	}
+	result.Issues = upgradeIssues(result)
+	return result
+}
+
+// upgradeIssues flags missing, slow, multi-hop, temporary and cross-host
+// upgrades
+func upgradeIssues(result *UpgradeResult) []string {
+	if !result.Upgraded {
+		return []string{"plain HTTP is not redirected to HTTPS"}
+	}
+
+	var issues []string
+	if len(result.Hops) > 1 {
+		issues = append(issues, fmt.Sprintf("upgrade takes %d redirects; redirect straight to HTTPS", len(result.Hops)))
+	}
+	if !result.Permanent {
+		issues = append(issues, "upgrade uses a temporary redirect; use 301 or 308")
+	}
+	if !result.SameHost {
+		issues = append(issues, "upgrade changes the host; redirect to the same host over HTTPS first so HSTS applies to it")
+	}
+	if time.Duration(result.TotalMs)*time.Millisecond > slowUpgrade {
+		issues = append(issues, fmt.Sprintf("upgrade takes %dms", result.TotalMs))
+	}
+	return issues
+}
@@ -0,0 +1,111 @@
+package internal
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// ErrInvalidWeights is returned for a weight override naming an unknown
+// header or assigning a negative weight
+var ErrInvalidWeights = errors.New("invalid weights")
+
+// defaultSecurityHeaders keeps the built-in definitions while
+// securityHeaders carries the configured weights
+var defaultSecurityHeaders = securityHeaders
+
+// validateWeights checks that every overridden header is a checked header
+// and that no weight is negative
+func validateWeights(weights map[string]int) error {
+	for name, weight := range weights {
+		if !knownHeader(name) {
+			return fmt.Errorf("%w: unknown header %q", ErrInvalidWeights, name)
+		}
+		if weight < 0 {
+			return fmt.Errorf("%w: weight of %s must not be negative, got %d", ErrInvalidWeights, name, weight)
+		}
+	}
+	return nil
+}
+
+// knownHeader reports whether name is one of the checked headers
+func knownHeader(name string) bool {
+	for _, header := range defaultSecurityHeaders {
+		if header.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// applyWeights returns copies of headers with the given weights; headers
+// not listed keep their weight
+func applyWeights(headers []SecurityHeader, weights map[string]int) []SecurityHeader {
+	if len(weights) == 0 {
+		return headers
+	}
+
+	weighted := make([]SecurityHeader, len(headers))
+	for i, header := range headers {
+		if weight, ok := weights[header.Name]; ok {
+			header.Weight = weight
+		}
+		weighted[i] = header
+	}
+	return weighted
+}
+
+// LoadWeights reads header weights from a JSON file mapping header names
+// to weights, e.g. {"Content-Security-Policy": 30}
+func LoadWeights(path string) (map[string]int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var weights map[string]int
+	if err := json.Unmarshal(data, &weights); err != nil {
+		return nil, fmt.Errorf("weights file %s: %w", path, err)
+	}
+	return weights, nil
+}
+
+// HeaderDefinition describes a checked header and how much it weighs
+type HeaderDefinition struct {
+	Name        string             `json:"name"`
+	Description string             `json:"description"`
+	Weight      int                `json:"weight"`
+	Tier        SecurityHeaderTier `json:"tier"`
+	Aliases     []string           `json:"aliases,omitempty"`
+}
+
+// HeaderReference lists the checked headers and the grade thresholds their
+// scores are graded by
+type HeaderReference struct {
+	Headers         []HeaderDefinition `json:"headers"`
+	GradeThresholds GradeThresholds    `json:"gradeThresholds"`
+}
+
+// HeaderReferenceFor returns the header definitions for the site type and
+// the active grade thresholds
+func HeaderReferenceFor(siteType SiteType) HeaderReference {
+	return HeaderReference{Headers: HeaderDefinitions(siteType), GradeThresholds: config.GradeThresholds}
+}
+
+// HeaderDefinitions lists the checked headers with the active weights,
+// including WEIGHTS_FILE overrides and the given site type preset
+func HeaderDefinitions(siteType SiteType) []HeaderDefinition {
+	headers := applySiteType(securityHeaders, siteType)
+	definitions := make([]HeaderDefinition, 0, len(headers))
+	for _, header := range headers {
+		definitions = append(definitions, HeaderDefinition{
+			Name:        header.Name,
+			Description: header.Description,
+			Weight:      header.Weight,
+			Tier:        header.Tier,
+			Aliases:     header.Aliases,
+		})
+	}
+	return definitions
+}
@@ -1,17 +1,161 @@
 package main
 
 import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
 	"log"
+	"net/http"
 	"os"
+	"os/signal"
+	"regexp"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/atakanaydinbas/HTTP-Header-Security-Analyzer/internal"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
+	"github.com/gofiber/fiber/v2/middleware/limiter"
 )
 
 type AnalyzeRequest struct {
-	URL string `json:"url"`
+	URL                     string                     `json:"url" openapi:"required"`
+	RequestID               string                     `json:"requestId"`
+	Filter                  string                     `json:"filter"`
+	IncludeAllHeaders       bool                       `json:"includeAllHeaders"`
+	Preflight               *internal.PreflightOptions `json:"preflight"`
+	Resolvers               []string                   `json:"resolvers"`
+	CheckReportEndpoints    bool                       `json:"checkReportEndpoints"`
+	IncludeAssets           bool                       `json:"includeAssets"`
+	IncludeRequestInfo      bool                       `json:"includeRequestInfo"`
+	SiteType                internal.SiteType          `json:"siteType"`
+	RequireValidCertificate bool                       `json:"requireValidCertificate"`
+	RequireSuccess          bool                       `json:"requireSuccess"`
+	InsecureSkipVerify      bool                       `json:"insecureSkipVerify"`
+	IncludeQualityScore     bool                       `json:"includeQualityScore"`
+	IncludeConfidence       bool                       `json:"includeConfidence"`
+	Scale                   int                        `json:"scale"`
+	Authenticated           *bool                      `json:"authenticated"`
+	Sort                    string                     `json:"sort"`
+	CheckUpgrade            bool                       `json:"checkUpgrade"`
+	CheckTransport          bool                       `json:"checkTransport"`
+	CheckSchemes            bool                       `json:"checkSchemes"`
+	ConfigDiff              string                     `json:"configDiff"`
+	TimeoutSeconds          int                        `json:"timeoutSeconds"`
+	FollowRedirects         bool                       `json:"followRedirects"`
+	Method                  string                     `json:"method"`
+	Headers                 map[string]string          `json:"headers"`
+	UserAgent               string                     `json:"userAgent"`
+	Weights                 map[string]int             `json:"weights"`
+}
+
+type BatchRequest struct {
+	URLs            []string `json:"urls" openapi:"required"`
+	RequestID       string   `json:"requestId"`
+	Concurrency     int      `json:"concurrency"`
+	DeadlineSeconds int      `json:"deadlineSeconds"`
+}
+
+const (
+	// maxBatchURLs limits how many URLs a single batch request may contain
+	maxBatchURLs = 100
+	// maxBatchDeadline caps the overall time a batch may run
+	maxBatchDeadline = 10 * time.Minute
+)
+
+// batchContext derives a context from parent bounded by the requested
+// batch deadline
+func batchContext(parent context.Context, req BatchRequest) (context.Context, context.CancelFunc) {
+	if req.DeadlineSeconds <= 0 {
+		return context.WithCancel(parent)
+	}
+
+	deadline := time.Duration(req.DeadlineSeconds) * time.Second
+	if deadline > maxBatchDeadline {
+		deadline = maxBatchDeadline
+	}
+	return context.WithTimeout(parent, deadline)
+}
+
+// CompareRequest names the two scans to diff. Each side is either a stored
+// analysis result or a URL to analyze now.
+type CompareRequest struct {
+	Before    *internal.AnalysisResult `json:"before"`
+	After     *internal.AnalysisResult `json:"after"`
+	BeforeURL string                   `json:"beforeUrl"`
+	AfterURL  string                   `json:"afterUrl"`
+}
+
+// ValidateRequest names the URL to check for reachability
+type ValidateRequest struct {
+	URL string `json:"url" openapi:"required"`
+}
+
+type ComparePairRequest struct {
+	Staging    string `json:"staging" openapi:"required"`
+	Production string `json:"production" openapi:"required"`
+}
+
+// maxRequestIDLength caps the client-supplied request ID echoed in results
+const maxRequestIDLength = 128
+
+// maxScale is the largest scale a score can be mapped onto
+const maxScale = 100
+
+// maxTimeoutSeconds caps the per-request client timeout a caller may ask for
+const maxTimeoutSeconds = 60
+
+type AnalyzeHeadersRequest struct {
+	Headers map[string]string `json:"headers" openapi:"required"`
+	HTTPS   bool              `json:"https"`
+}
+
+type VerifyResponse struct {
+	Valid bool `json:"valid"`
+}
+
+type CrawlRequest struct {
+	URL      string `json:"url" openapi:"required"`
+	MaxPages int    `json:"maxPages"`
+}
+
+// maxCrawlDuration bounds how long a single crawl may run
+const maxCrawlDuration = 2 * time.Minute
+
+var (
+	// results holds the latest result per analyzed URL, or nil when disabled
+	results *internal.ResultStore
+	// history records every analysis for trend reporting
+	history *internal.History
+	// signingEnabled is set when RESULT_SIGNING_SECRET is configured
+	signingEnabled bool
+	// reference is the known-good server ?baseline=true diffs against, or
+	// nil when none is configured
+	reference *internal.ReferenceServer
+	// resultCache reuses recent analyses, or is nil when disabled
+	resultCache *internal.ResultCache
+)
+
+// analyzeCacheVariant describes everything besides the URL and method that
+// shapes an /analyze response, so only identical requests share a result
+func analyzeCacheVariant(c *fiber.Ctx, req AnalyzeRequest, format string) string {
+	req.URL, req.Method, req.RequestID = "", "", ""
+	options, _ := json.Marshal(req)
+	return format + "\n" + c.Query("compliance") + "\n" + c.Query("profile") + "\n" + c.Query("verbose") + "\n" + string(options)
+}
+
+// recordResult stores a completed analysis in the result store and history
+func recordResult(result *internal.AnalysisResult) {
+	results.Record(result)
+	if err := history.Record(result); err != nil {
+		log.Printf("Failed to record history for %s: %v", result.URL, err)
+	}
 }
 
 type ErrorResponse struct {
@@ -32,27 +176,914 @@ func analyzeHandler(c *fiber.Ctx) error {
 		})
 	}
 
-	result, err := internal.AnalyzeURL(req.URL)
+	if len(req.RequestID) > maxRequestIDLength {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error: "requestId must be at most 128 characters",
+		})
+	}
+
+	if req.Preflight != nil && req.Preflight.Origin == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error: "Preflight origin is required",
+		})
+	}
+
+	if len(req.Resolvers) > internal.MaxResolvers {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error: "Too many resolvers",
+		})
+	}
+
+	if req.Scale < 0 || req.Scale > maxScale {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error: "Scale must be between 1 and 100, or 0 for no scaling",
+		})
+	}
+
+	format := responseFormat(c)
+	if !internal.ValidFormat(format) {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error: internal.ErrUnknownFormat.Error(),
+		})
+	}
+
+	if req.RequireValidCertificate && req.InsecureSkipVerify {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error: "requireValidCertificate and insecureSkipVerify cannot be combined",
+		})
+	}
+
+	if req.TimeoutSeconds < 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error: "timeoutSeconds must not be negative",
+		})
+	}
+	if req.TimeoutSeconds > maxTimeoutSeconds {
+		req.TimeoutSeconds = maxTimeoutSeconds
+	}
+
+	if c.QueryBool("sign") && !signingEnabled {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error: "Result signing is not configured (set RESULT_SIGNING_SECRET)",
+		})
+	}
+
+	if c.QueryBool("baseline") && reference == nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error: "No reference server configured (set REFERENCE_URL)",
+		})
+	}
+
+	if req.ConfigDiff != "" && !internal.ValidConfigServer(req.ConfigDiff) {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error: "configDiff must be one of nginx, caddy, apache",
+		})
+	}
+
+	profile := internal.ScoringProfile(c.Query("profile"))
+	if !internal.ValidProfile(profile) {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error: internal.ErrUnknownProfile.Error(),
+		})
+	}
+
+	if !internal.ValidSort(req.Sort) {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error: "sort must be one of weight, name, tier",
+		})
+	}
+
+	if !internal.ValidSiteType(req.SiteType) {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error: "siteType must be one of app, api, static",
+		})
+	}
+
+	if !internal.ValidMethod(req.Method) {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error: internal.ErrUnknownMethod.Error(),
+		})
+	}
+
+	opts := internal.Options{
+		IncludeAllHeaders:       req.IncludeAllHeaders,
+		Verbose:                 c.QueryBool("verbose"),
+		Preflight:               req.Preflight,
+		Resolvers:               req.Resolvers,
+		CheckReportEndpoints:    req.CheckReportEndpoints,
+		IncludeAssets:           req.IncludeAssets,
+		IncludeRequestInfo:      req.IncludeRequestInfo,
+		SiteType:                req.SiteType,
+		RequireValidCertificate: req.RequireValidCertificate,
+		RequireSuccess:          req.RequireSuccess,
+		InsecureSkipVerify:      req.InsecureSkipVerify,
+		IncludeQualityScore:     req.IncludeQualityScore,
+		IncludeConfidence:       req.IncludeConfidence,
+		Scale:                   req.Scale,
+		Authenticated:           req.Authenticated,
+		Sort:                    req.Sort,
+		CheckUpgrade:            req.CheckUpgrade,
+		CheckTransport:          req.CheckTransport,
+		CheckSchemes:            req.CheckSchemes,
+		Profile:                 profile,
+		ConfigServer:            req.ConfigDiff,
+		Timeout:                 time.Duration(req.TimeoutSeconds) * time.Second,
+		FollowRedirects:         req.FollowRedirects,
+		Method:                  req.Method,
+		RequestHeaders:          req.Headers,
+		UserAgent:               req.UserAgent,
+		Weights:                 req.Weights,
+		IncludeCompliance:       c.QueryBool("compliance"),
+	}
+	if req.Filter != "" {
+		filter, err := regexp.Compile(req.Filter)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+				Error: "Invalid filter: " + err.Error(),
+			})
+		}
+		opts.Filter = filter
+	}
+
+	cacheKey := internal.CacheKey(req.URL, req.Method, analyzeCacheVariant(c, req, format))
+	var result *internal.AnalysisResult
+	cached := false
+	if !c.QueryBool("nocache") {
+		result, cached = resultCache.Get(cacheKey)
+	}
+	var err error
+	if !cached {
+		result, err = internal.AnalyzeURLWithOptionsContext(c.UserContext(), req.URL, opts)
+	}
+	if errors.Is(err, internal.ErrInvalidWeights) {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error: err.Error(),
+		})
+	}
+	if errors.Is(err, internal.ErrNoHeadersMatched) {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error: "Filter does not match any checked header",
+		})
+	}
+	if status, message, ok := targetErrorStatus(err); ok {
+		return c.Status(status).JSON(ErrorResponse{Error: message})
+	}
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
 			Error: "Failed to analyze URL: " + err.Error(),
 		})
 	}
+	if !cached {
+		resultCache.Put(cacheKey, result)
+	}
+	result.RequestID = req.RequestID
+
+	if c.QueryBool("baseline") {
+		baseline, err := reference.Compare(c.UserContext(), result)
+		if err != nil {
+			return c.Status(fiber.StatusBadGateway).JSON(ErrorResponse{
+				Error: "Failed to analyze reference server: " + err.Error(),
+			})
+		}
+		result.Baseline = baseline
+	}
+
+	if c.QueryBool("sign") {
+		if err := internal.SignResult(result); err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
+				Error: "Failed to sign result: " + err.Error(),
+			})
+		}
+	}
+	if !cached {
+		recordResult(result)
+	}
+
+	if format != internal.FormatJSON {
+		formatted, err := internal.Format(result, format)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
+				Error: "Failed to format result: " + err.Error(),
+			})
+		}
+		c.Set(fiber.HeaderContentType, formatContentTypes[format])
+		return c.SendString(formatted)
+	}
+
+	if fields := splitList(c.Query("fields")); len(fields) > 0 {
+		projected, ignored, err := projectFields(result, fields)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
+				Error: "Failed to project fields: " + err.Error(),
+			})
+		}
+		if len(ignored) > 0 {
+			c.Set("X-Ignored-Fields", strings.Join(ignored, ","))
+		}
+		return c.JSON(projected)
+	}
 
 	return c.JSON(result)
 }
 
+// fetchErrorResponses maps each kind of fetch failure to its status code
+// and the message shown to the user
+var fetchErrorResponses = map[internal.FetchErrorKind]struct {
+	status  int
+	message string
+}{
+	internal.FetchDNS:               {fiber.StatusBadRequest, "Could not resolve host"},
+	internal.FetchConnectionRefused: {fiber.StatusBadGateway, "Connection refused by target"},
+	internal.FetchTimeout:           {fiber.StatusGatewayTimeout, "Target did not respond in time"},
+	internal.FetchTLS:               {fiber.StatusBadGateway, "TLS handshake with target failed"},
+	internal.FetchNetwork:           {fiber.StatusBadGateway, "Failed to reach target"},
+}
+
+// targetErrorStatus returns the status code and message for an error
+// caused by the target URL or by fetching it, reporting false for any
+// other error
+func targetErrorStatus(err error) (int, string, bool) {
+	var certErr *internal.CertificateError
+	var fetchErr *internal.FetchError
+	var statusErr *internal.StatusError
+	switch {
+	case errors.Is(err, internal.ErrInvalidURL):
+		return fiber.StatusBadRequest, err.Error(), true
+	case errors.Is(err, internal.ErrBlockedAddress):
+		return fiber.StatusForbidden, err.Error(), true
+	case errors.Is(err, internal.ErrFetchQueueTimeout):
+		return fiber.StatusServiceUnavailable, "Server is busy, try again later", true
+	case errors.As(err, &certErr):
+		return fiber.StatusUnprocessableEntity, "Untrusted certificate: " + certErr.Err.Error(), true
+	case errors.As(err, &statusErr):
+		return fiber.StatusUnprocessableEntity, "Target responded with status " + strconv.Itoa(statusErr.StatusCode), true
+	case errors.As(err, &fetchErr):
+		response := fetchErrorResponses[fetchErr.Kind]
+		return response.status, response.message + ": " + err.Error(), true
+	}
+	return 0, "", false
+}
+
+// formatContentTypes maps each output format to its media type
+var formatContentTypes = map[string]string{
+	internal.FormatJSON:     fiber.MIMEApplicationJSON,
+	internal.FormatText:     "text/plain; charset=utf-8",
+	internal.FormatMarkdown: "text/markdown; charset=utf-8",
+}
+
+// responseFormat picks the output format from ?format=, falling back to
+// content negotiation on the Accept header
+func responseFormat(c *fiber.Ctx) string {
+	if format := c.Query("format"); format != "" {
+		return format
+	}
+	switch c.Accepts(fiber.MIMEApplicationJSON, "text/plain", "text/markdown") {
+	case "text/plain":
+		return internal.FormatText
+	case "text/markdown":
+		return internal.FormatMarkdown
+	default:
+		return internal.FormatJSON
+	}
+}
+
+// projectFields keeps only the named top-level JSON fields of v. Names
+// that v does not have, or that are empty in it, are returned as ignored.
+func projectFields(v any, fields []string) (map[string]json.RawMessage, []string, error) {
+	encoded, err := json.Marshal(v)
+	if err != nil {
+		return nil, nil, err
+	}
+	var all map[string]json.RawMessage
+	if err := json.Unmarshal(encoded, &all); err != nil {
+		return nil, nil, err
+	}
+
+	projected := make(map[string]json.RawMessage, len(fields))
+	var ignored []string
+	for _, field := range fields {
+		if value, ok := all[field]; ok {
+			projected[field] = value
+		} else {
+			ignored = append(ignored, field)
+		}
+	}
+	return projected, ignored, nil
+}
+
+// validateBatchRequest returns the reason a batch request is rejected, or
+// an empty string when it is valid
+func validateBatchRequest(req BatchRequest) string {
+	switch {
+	case len(req.URLs) == 0:
+		return "At least one URL is required"
+	case len(req.URLs) > maxBatchURLs:
+		return "Too many URLs in batch"
+	case len(req.RequestID) > maxRequestIDLength:
+		return "requestId must be at most 128 characters"
+	}
+	return ""
+}
+
+// runBatch analyzes the URLs of a validated batch request and records the
+// successful results. The batch is abandoned once ctx is done.
+func runBatch(ctx context.Context, req BatchRequest) *internal.BatchReport {
+	ctx, cancel := batchContext(ctx, req)
+	defer cancel()
+
+	report := internal.AnalyzeBatch(ctx, req.URLs, req.Concurrency)
+	report.SetRequestID(req.RequestID)
+	for _, item := range report.Items {
+		if item.Result != nil {
+			recordResult(item.Result)
+		}
+	}
+	return report
+}
+
+func analyzeBatchHandler(c *fiber.Ctx) error {
+	var req BatchRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error: "Invalid request body",
+		})
+	}
+
+	if msg := validateBatchRequest(req); msg != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{Error: msg})
+	}
+
+	return c.JSON(runBatch(c.UserContext(), req))
+}
+
+// StreamSummary is the final event of a streamed batch
+type StreamSummary struct {
+	RequestID string `json:"requestId,omitempty"`
+	Completed int    `json:"completed"`
+	Skipped   int    `json:"skipped"`
+	Note      string `json:"note,omitempty"`
+}
+
+// streamBatchRequest reads a batch request from the JSON body of a POST, or
+// from the query of a GET so that browsers can use EventSource: repeated
+// url parameters plus requestId, concurrency and deadlineSeconds
+func streamBatchRequest(c *fiber.Ctx) (BatchRequest, error) {
+	var req BatchRequest
+	if c.Method() == fiber.MethodPost {
+		err := c.BodyParser(&req)
+		return req, err
+	}
+
+	for _, url := range c.Context().QueryArgs().PeekMulti("url") {
+		req.URLs = append(req.URLs, string(url))
+	}
+	req.RequestID = c.Query("requestId")
+	req.Concurrency = c.QueryInt("concurrency")
+	req.DeadlineSeconds = c.QueryInt("deadlineSeconds")
+	return req, nil
+}
+
+// writeEvent writes data as a JSON Server-Sent Event and flushes it, so an
+// error means the client has gone away
+func writeEvent(w *bufio.Writer, event string, data any) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	w.WriteString("event: " + event + "\n")
+	w.WriteString("data: ")
+	w.Write(payload)
+	w.WriteString("\n\n")
+	return w.Flush()
+}
+
+// analyzeStreamHandler analyzes a batch like analyzeBatchHandler but streams
+// each item as a Server-Sent Event as soon as it completes: a "result"
+// event for a successful analysis, an "error" event for a failed one and a
+// final "done" event with the counts. The batch is abandoned when the
+// client disconnects.
+func analyzeStreamHandler(c *fiber.Ctx) error {
+	req, err := streamBatchRequest(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error: "Invalid request body",
+		})
+	}
+
+	if msg := validateBatchRequest(req); msg != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{Error: msg})
+	}
+
+	// The stream is written after the handler returns, so nothing may use c
+	// from here on
+	ctx, cancel := batchContext(c.UserContext(), req)
+	c.Set(fiber.HeaderContentType, "text/event-stream")
+	c.Set(fiber.HeaderCacheControl, "no-cache")
+	c.Set("X-Accel-Buffering", "no")
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer cancel()
+
+		report := internal.AnalyzeBatchFunc(ctx, req.URLs, req.Concurrency, func(item internal.BatchItem) {
+			item.RequestID = req.RequestID
+			event := "error"
+			if item.Result != nil {
+				item.Result.RequestID = req.RequestID
+				recordResult(item.Result)
+				event = "result"
+			}
+			if err := writeEvent(w, event, item); err != nil {
+				cancel()
+			}
+		})
+
+		writeEvent(w, "done", StreamSummary{
+			RequestID: req.RequestID,
+			Completed: len(report.Items),
+			Skipped:   report.Skipped,
+			Note:      report.Note,
+		})
+	})
+	return nil
+}
+
+func exportCSVHandler(c *fiber.Ctx) error {
+	var req BatchRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error: "Invalid request body",
+		})
+	}
+
+	if msg := validateBatchRequest(req); msg != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{Error: msg})
+	}
+
+	report := runBatch(c.UserContext(), req)
+
+	var buf bytes.Buffer
+	if err := internal.WriteBatchCSV(&buf, report.Items); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
+			Error: "Failed to export CSV: " + err.Error(),
+		})
+	}
+
+	c.Set(fiber.HeaderContentType, "text/csv; charset=utf-8")
+	c.Set(fiber.HeaderContentDisposition, `attachment; filename="analysis.csv"`)
+	c.Set("X-Batch-Skipped", strconv.Itoa(report.Skipped))
+	if req.RequestID != "" {
+		c.Set("X-Request-Id", req.RequestID)
+	}
+	return c.Send(buf.Bytes())
+}
+
+// compareSide returns the given result, or analyzes and records url when
+// there is none
+func compareSide(ctx context.Context, result *internal.AnalysisResult, url string) (*internal.AnalysisResult, error) {
+	if result != nil {
+		return result, nil
+	}
+	result, err := internal.AnalyzeURLContext(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	recordResult(result)
+	return result, nil
+}
+
+func compareHandler(c *fiber.Ctx) error {
+	var req CompareRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error: "Invalid request body",
+		})
+	}
+
+	if (req.Before == nil) == (req.BeforeURL == "") || (req.After == nil) == (req.AfterURL == "") {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error: "Each side needs exactly one of before or beforeUrl, and after or afterUrl",
+		})
+	}
+	for _, result := range []*internal.AnalysisResult{req.Before, req.After} {
+		if result != nil && (len(result.Summary) == 0 || !internal.ValidGrade(result.Grade)) {
+			return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+				Error: "An analysis result with summary and grade is required",
+			})
+		}
+	}
+
+	before, err := compareSide(c.UserContext(), req.Before, req.BeforeURL)
+	var after *internal.AnalysisResult
+	if err == nil {
+		after, err = compareSide(c.UserContext(), req.After, req.AfterURL)
+	}
+	if status, message, ok := targetErrorStatus(err); ok {
+		return c.Status(status).JSON(ErrorResponse{Error: message})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
+			Error: "Failed to analyze URL: " + err.Error(),
+		})
+	}
+
+	return c.JSON(internal.Compare(before, after))
+}
+
+func comparePairHandler(c *fiber.Ctx) error {
+	var req ComparePairRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error: "Invalid request body",
+		})
+	}
+
+	if req.Staging == "" || req.Production == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error: "Both staging and production URLs are required",
+		})
+	}
+
+	pair, err := internal.ComparePair(c.UserContext(), req.Staging, req.Production)
+	if status, message, ok := targetErrorStatus(err); ok {
+		return c.Status(status).JSON(ErrorResponse{Error: message})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
+			Error: "Failed to analyze URL: " + err.Error(),
+		})
+	}
+	recordResult(pair.Staging)
+	recordResult(pair.Production)
+
+	return c.JSON(pair)
+}
+
+func crawlHandler(c *fiber.Ctx) error {
+	var req CrawlRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error: "Invalid request body",
+		})
+	}
+
+	if req.URL == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error: "URL is required",
+		})
+	}
+
+	if req.MaxPages < 0 || req.MaxPages > internal.MaxCrawlPages {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error: "Too many pages to crawl",
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(c.UserContext(), maxCrawlDuration)
+	defer cancel()
+
+	report, err := internal.Crawl(ctx, req.URL, req.MaxPages)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	return c.JSON(report)
+}
+
+func resultsHandler(c *fiber.Ctx) error {
+	if results == nil {
+		return c.Status(fiber.StatusNotFound).JSON(ErrorResponse{
+			Error: "Result store is disabled",
+		})
+	}
+
+	grade := strings.ToUpper(c.Query("grade"))
+	if grade != "" && !internal.ValidGrade(grade) {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error: "Grade must be one of A, B, C, D or F, or a Mozilla profile grade such as A+ or B-",
+		})
+	}
+
+	limit := c.QueryInt("limit", 0)
+	if limit < 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error: "Limit must not be negative",
+		})
+	}
+
+	return c.JSON(results.Query(grade, limit))
+}
+
+// defaultScorecardPeriod is the reporting period when none is requested
+const defaultScorecardPeriod = 30 * 24 * time.Hour
+
+// parsePeriod parses a reporting period given in days ("7d") or as a
+// duration ("12h")
+func parsePeriod(s string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, err
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	return time.ParseDuration(s)
+}
+
+func scorecardHandler(c *fiber.Ctx) error {
+	period := defaultScorecardPeriod
+	if raw := c.Query("period"); raw != "" {
+		parsed, err := parsePeriod(raw)
+		if err != nil || parsed <= 0 {
+			return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+				Error: "Period must be a positive number of days (e.g. 7d) or a duration (e.g. 12h)",
+			})
+		}
+		period = parsed
+	}
+
+	return c.JSON(history.Scorecard(period, time.Now().UTC()))
+}
+
+func grafanaHandler(c *fiber.Ctx) error {
+	return c.SendStatus(fiber.StatusOK)
+}
+
+func grafanaSearchHandler(c *fiber.Ctx) error {
+	return c.JSON(history.GrafanaSearch())
+}
+
+func grafanaQueryHandler(c *fiber.Ctx) error {
+	var query internal.GrafanaQuery
+	if err := c.BodyParser(&query); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error: "Invalid request body",
+		})
+	}
+
+	return c.JSON(history.GrafanaQuery(query))
+}
+
+func headersHandler(c *fiber.Ctx) error {
+	siteType := internal.SiteType(c.Query("siteType"))
+	if !internal.ValidSiteType(siteType) {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error: internal.ErrUnknownSiteType.Error(),
+		})
+	}
+	return c.JSON(internal.HeaderReferenceFor(siteType))
+}
+
+func complianceHandler(c *fiber.Ctx) error {
+	return c.JSON(internal.ComplianceMatrix())
+}
+
+func verifyHandler(c *fiber.Ctx) error {
+	if !signingEnabled {
+		return c.Status(fiber.StatusNotFound).JSON(ErrorResponse{
+			Error: "Result signing is not configured",
+		})
+	}
+
+	var result internal.AnalysisResult
+	if err := c.BodyParser(&result); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error: "Invalid request body",
+		})
+	}
+
+	if result.Signature == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error: "Result has no signature",
+		})
+	}
+
+	valid, err := internal.VerifyResult(&result)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
+			Error: "Failed to verify result: " + err.Error(),
+		})
+	}
+	return c.JSON(VerifyResponse{Valid: valid})
+}
+
+// validateHandler checks that a URL is reachable with a HEAD request,
+// without analyzing it
+func validateHandler(c *fiber.Ctx) error {
+	var req ValidateRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error: "Invalid request body",
+		})
+	}
+
+	if req.URL == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error: "URL is required",
+		})
+	}
+
+	reachability, err := internal.CheckReachable(c.UserContext(), req.URL)
+	if status, message, ok := targetErrorStatus(err); ok {
+		return c.Status(status).JSON(ErrorResponse{Error: message})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
+			Error: "Failed to check URL: " + err.Error(),
+		})
+	}
+	return c.JSON(reachability)
+}
+
+func nextGradeHandler(c *fiber.Ctx) error {
+	var result internal.AnalysisResult
+	if err := c.BodyParser(&result); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error: "Invalid request body",
+		})
+	}
+
+	if len(result.Summary) == 0 || !internal.ValidGrade(result.Grade) {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error: "An analysis result with summary and grade is required",
+		})
+	}
+	if result.Profile != internal.ProfileDefault {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error: "Grade plans are only available for the default profile",
+		})
+	}
+	if err := internal.ValidateSummary(result.Summary); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	next := internal.ComputeNextGrade(&result)
+	if next == nil {
+		return c.JSON(internal.NextGrade{Current: result.Grade, Fixes: []string{}, ProjectedScore: result.Score})
+	}
+	return c.JSON(next)
+}
+
+// analyzeHeadersHandler scores captured response headers without fetching
+// the site they came from
+func analyzeHeadersHandler(c *fiber.Ctx) error {
+	var req AnalyzeHeadersRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error: "Invalid request body",
+		})
+	}
+
+	if len(req.Headers) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error: "Headers are required",
+		})
+	}
+
+	header := make(http.Header, len(req.Headers))
+	for name, value := range req.Headers {
+		header.Add(name, value)
+	}
+
+	return c.JSON(internal.AnalyzeHeaders(header, req.HTTPS))
+}
+
+func analyzeRawHandler(c *fiber.Ctx) error {
+	var req internal.RawRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error: "Invalid request body",
+		})
+	}
+
+	if req.Target == "" || req.Method == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Error: "Target and method are required",
+		})
+	}
+
+	analysis, err := internal.AnalyzeRawRequest(c.UserContext(), req)
+	if errors.Is(err, internal.ErrHostNotAllowed) {
+		return c.Status(fiber.StatusForbidden).JSON(ErrorResponse{
+			Error: "Target host is not in the raw request allowlist",
+		})
+	}
+	if errors.Is(err, internal.ErrBlockedAddress) {
+		return c.Status(fiber.StatusForbidden).JSON(ErrorResponse{
+			Error: err.Error(),
+		})
+	}
+	if errors.Is(err, internal.ErrFetchQueueTimeout) {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(ErrorResponse{
+			Error: "Server is busy, try again later",
+		})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusBadGateway).JSON(ErrorResponse{
+			Error: "Raw request failed: " + err.Error(),
+		})
+	}
+
+	return c.JSON(analysis)
+}
+
+func metricsHandler(c *fiber.Ctx) error {
+	var buf bytes.Buffer
+	if err := internal.WriteMetrics(&buf); err != nil {
+		return err
+	}
+	c.Set(fiber.HeaderContentType, "text/plain; version=0.0.4; charset=utf-8")
+	return c.Send(buf.Bytes())
+}
+
 func healthHandler(c *fiber.Ctx) error {
 	return c.JSON(fiber.Map{
 		"status": "ok",
 	})
 }
 
+func main() {
+	var cli cliOptions
+	flag.StringVar(&cli.URL, "url", "", "analyze a single URL, print the result as JSON and exit instead of starting the server")
+	flag.BoolVar(&cli.Strict, "strict", false, "with -url, exit non-zero when any critical header is missing or weak")
+	flag.StringVar(&cli.MinGrade, "min-grade", "", "with -url, exit non-zero when the grade is below this one (A, B, C, D or F)")
+	flag.StringVar(&cli.Format, "format", internal.FormatJSON, "with -url, output format: json, text or markdown")
+	flag.Parse()
+
+	cfg, err := loadConfig()
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := internal.SetConfig(cfg); err != nil {
+		log.Fatal(err)
+	}
+	signingEnabled = cfg.SigningSecret != ""
 
+	if cli.URL != "" {
+		os.Exit(runCLI(cli))
+	}
 
+	storeSize, err := resultStoreSize()
+	if err != nil {
+		log.Fatal(err)
+	}
+	if storeSize > 0 {
+		results = internal.NewResultStore(storeSize)
+	}
 
+	history, err = internal.OpenHistory(os.Getenv("HISTORY_FILE"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer history.Close()
+
+	reference, err = loadReference()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	cacheTTL, err := resultCacheTTL()
+	if err != nil {
+		log.Fatal(err)
+	}
+	if cacheTTL > 0 {
+		resultCache = internal.NewResultCache(cacheTTL)
+	}
+
+	rateLimit, err := loadRateLimit()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	gracePeriod, err := shutdownTimeout()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// ctx is cancelled on SIGINT or SIGTERM, which starts a graceful shutdown
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	schedule, err := loadSchedule()
+	if err != nil {
+		log.Fatal(err)
+	}
+	if schedule.Interval > 0 {
+		scheduler := &internal.Scheduler{
+			URLs:        schedule.URLs,
+			Interval:    schedule.Interval,
+			Concurrency: schedule.Concurrency,
+			OnResult:    recordResult,
+		}
+		if rateLimit.Max > 0 {
+			scheduler.Limiter = internal.NewRateLimiter(rateLimit.Max, rateLimit.Window)
+		}
+		log.Printf("Rescanning %d URLs every %s", len(schedule.URLs), schedule.Interval)
+		go scheduler.Run(ctx)
+	}
 
-func main() {
 	app := fiber.New(fiber.Config{
 		ErrorHandler: func(c *fiber.Ctx, err error) error {
 			code := fiber.StatusInternalServerError
@@ -72,17 +1103,73 @@ func main() {
 		AllowHeaders: "Content-Type",
 	}))
 
-	// Routes
-	app.Post("/analyze", analyzeHandler)
-	app.Get("/health", healthHandler)
+	// Routes, mounted under the optional ROUTE_PREFIX
+	prefix := routePrefix()
+
+	// Per-IP rate limiting, so the analyzer cannot be used to flood targets.
+	// Health checks are exempt.
+	if rateLimit.Max > 0 {
+		app.Use(limiter.New(limiter.Config{
+			Next: func(c *fiber.Ctx) bool {
+				return c.Path() == prefix+"/health"
+			},
+			Max:        rateLimit.Max,
+			Expiration: rateLimit.Window,
+			LimitReached: func(c *fiber.Ctx) error {
+				return c.Status(fiber.StatusTooManyRequests).JSON(ErrorResponse{
+					Error: "Too many requests, try again later",
+				})
+			},
+		}))
+	}
+
+	api := app.Group(prefix)
+	api.Post("/analyze", analyzeHandler)
+	api.Post("/analyze/raw", analyzeRawHandler)
+	api.Post("/analyze-headers", analyzeHeadersHandler)
+	api.Post("/analyze-batch", analyzeBatchHandler)
+	api.Get("/analyze-stream", analyzeStreamHandler)
+	api.Post("/analyze-stream", analyzeStreamHandler)
+	api.Post("/export/csv", exportCSVHandler)
+	api.Post("/compare", compareHandler)
+	api.Post("/compare/pair", comparePairHandler)
+	api.Post("/crawl", crawlHandler)
+	api.Post("/verify", verifyHandler)
+	api.Post("/validate", validateHandler)
+	api.Post("/next-grade", nextGradeHandler)
+	api.Get("/results", resultsHandler)
+	api.Get("/scorecard", scorecardHandler)
+	api.Get("/compliance", complianceHandler)
+	api.Get("/headers", headersHandler)
+	api.Get("/metrics", metricsHandler)
+	api.Get("/health", healthHandler)
+	api.Get("/openapi.json", openAPIHandler)
+	api.Get("/docs", docsHandler)
+
+	grafana := api.Group("/grafana")
+	grafana.Get("/", grafanaHandler)
+	grafana.Post("/search", grafanaSearchHandler)
+	grafana.Post("/query", grafanaQueryHandler)
 
 	port := os.Getenv("PORT")
 	if port == "" {
 		port = "8080"
 	}
 
-	log.Printf("Server starting on port %s", port)
-	if err := app.Listen(":" + port); err != nil {
-		log.Fatal(err)
+	go func() {
+		log.Printf("Server starting on port %s", port)
+		if err := app.Listen(":" + port); err != nil {
+			log.Fatal(err)
+		}
+	}()
+
+	<-ctx.Done()
+	stop()
+	log.Printf("Shutting down, waiting up to %s for in-flight requests", gracePeriod)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), gracePeriod)
+	defer cancel()
+	if err := app.ShutdownWithContext(shutdownCtx); err != nil {
+		log.Printf("Shutdown did not complete cleanly: %v", err)
 	}
+	log.Print("Server stopped")
 }
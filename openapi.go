@@ -0,0 +1,190 @@
+package main
+
+import (
+	"github.com/atakanaydinbas/HTTP-Header-Security-Analyzer/internal"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// apiVersion is the version of the API described by the OpenAPI document
+const apiVersion = "1.0"
+
+// targetErrors are the error statuses targetErrorStatus answers with
+var targetErrors = []int{
+	fiber.StatusBadRequest,
+	fiber.StatusForbidden,
+	fiber.StatusUnprocessableEntity,
+	fiber.StatusBadGateway,
+	fiber.StatusServiceUnavailable,
+	fiber.StatusGatewayTimeout,
+}
+
+// batchQuery are the query parameters of a GET /analyze-stream
+var batchQuery = []internal.OpenAPIParameter{
+	{Name: "url", Type: "string", Description: "URL to analyze; repeat for each URL"},
+	{Name: "requestId", Type: "string", Description: "ID echoed in every item"},
+	{Name: "concurrency", Type: "integer", Description: "URLs analyzed at once"},
+	{Name: "deadlineSeconds", Type: "integer", Description: "time after which remaining URLs are skipped"},
+}
+
+// apiOperations describes every endpoint for the OpenAPI document. Keep it
+// in step with the routes registered in main.
+var apiOperations = []internal.OpenAPIOperation{
+	{
+		Method: fiber.MethodPost, Path: "/analyze", Summary: "Analyze the security headers of a URL",
+		Query: []internal.OpenAPIParameter{
+			{Name: "format", Type: "string", Description: "json (default), text or markdown"},
+			{Name: "fields", Type: "string", Description: "comma-separated top-level fields to return"},
+			{Name: "nocache", Type: "boolean", Description: "force a fresh analysis"},
+			{Name: "sign", Type: "boolean", Description: "add an HMAC signature to the result"},
+			{Name: "baseline", Type: "boolean", Description: "diff against the reference server"},
+			{Name: "compliance", Type: "boolean", Description: "map headers to compliance controls"},
+			{Name: "profile", Type: "string", Description: "scoring profile: default or mozilla"},
+			{Name: "verbose", Type: "boolean", Description: "attach every response header and list unchecked security headers"},
+		},
+		Request: AnalyzeRequest{}, Response: internal.AnalysisResult{},
+		Errors: append(targetErrors, fiber.StatusInternalServerError),
+	},
+	{
+		Method: fiber.MethodPost, Path: "/analyze/raw", Summary: "Send a hand-crafted raw HTTP request to an allowed host",
+		Request: internal.RawRequest{}, Response: internal.RawAnalysis{},
+		Errors: []int{fiber.StatusBadRequest, fiber.StatusForbidden, fiber.StatusBadGateway, fiber.StatusServiceUnavailable},
+	},
+	{
+		Method: fiber.MethodPost, Path: "/analyze-headers", Summary: "Score already captured response headers",
+		Request: AnalyzeHeadersRequest{}, Response: internal.AnalysisResult{},
+		Errors: []int{fiber.StatusBadRequest},
+	},
+	{
+		Method: fiber.MethodPost, Path: "/analyze-batch", Summary: "Analyze a batch of URLs",
+		Request: BatchRequest{}, Response: internal.BatchReport{},
+		Errors: []int{fiber.StatusBadRequest},
+	},
+	{
+		Method: fiber.MethodGet, Path: "/analyze-stream", Summary: "Stream batch results as Server-Sent Events",
+		Query: batchQuery, ContentType: "text/event-stream",
+		Errors: []int{fiber.StatusBadRequest},
+	},
+	{
+		Method: fiber.MethodPost, Path: "/analyze-stream", Summary: "Stream batch results as Server-Sent Events",
+		Request: BatchRequest{}, ContentType: "text/event-stream",
+		Errors: []int{fiber.StatusBadRequest},
+	},
+	{
+		Method: fiber.MethodPost, Path: "/export/csv", Summary: "Analyze a batch of URLs and export it as CSV",
+		Request: BatchRequest{}, ContentType: "text/csv",
+		Errors: []int{fiber.StatusBadRequest, fiber.StatusInternalServerError},
+	},
+	{
+		Method: fiber.MethodPost, Path: "/compare", Summary: "Diff two analyses",
+		Request: CompareRequest{}, Response: internal.Comparison{},
+		Errors: append(targetErrors, fiber.StatusInternalServerError),
+	},
+	{
+		Method: fiber.MethodPost, Path: "/compare/pair", Summary: "Diff a staging and a production URL",
+		Request: ComparePairRequest{}, Response: internal.PairComparison{},
+		Errors: append(targetErrors, fiber.StatusInternalServerError),
+	},
+	{
+		Method: fiber.MethodPost, Path: "/crawl", Summary: "Analyze the pages linked from a URL",
+		Request: CrawlRequest{}, Response: internal.CrawlReport{},
+		Errors: []int{fiber.StatusBadRequest},
+	},
+	{
+		Method: fiber.MethodPost, Path: "/verify", Summary: "Verify the signature of a signed result",
+		Request: internal.AnalysisResult{}, Response: VerifyResponse{},
+		Errors: []int{fiber.StatusBadRequest, fiber.StatusNotFound, fiber.StatusInternalServerError},
+	},
+	{
+		Method: fiber.MethodPost, Path: "/validate", Summary: "Check that a URL is reachable",
+		Request: ValidateRequest{}, Response: internal.Reachability{},
+		Errors: []int{fiber.StatusBadRequest, fiber.StatusForbidden, fiber.StatusServiceUnavailable, fiber.StatusInternalServerError},
+	},
+	{
+		Method: fiber.MethodPost, Path: "/next-grade", Summary: "List the cheapest fixes to reach the next grade",
+		Request: internal.AnalysisResult{}, Response: internal.NextGrade{},
+		Errors: []int{fiber.StatusBadRequest},
+	},
+	{
+		Method: fiber.MethodGet, Path: "/results", Summary: "List the latest result per analyzed URL",
+		Query: []internal.OpenAPIParameter{
+			{Name: "grade", Type: "string", Description: "only URLs at this grade"},
+			{Name: "limit", Type: "integer", Description: "maximum number of entries"},
+		},
+		Response: []internal.StoredResult{},
+		Errors:   []int{fiber.StatusBadRequest, fiber.StatusNotFound},
+	},
+	{
+		Method: fiber.MethodGet, Path: "/scorecard", Summary: "Summarize the recorded history",
+		Query:    []internal.OpenAPIParameter{{Name: "period", Type: "string", Description: "e.g. 7d or 24h"}},
+		Response: internal.Scorecard{},
+		Errors:   []int{fiber.StatusBadRequest},
+	},
+	{
+		Method: fiber.MethodGet, Path: "/compliance", Summary: "Map checked headers to compliance controls",
+		Response: []internal.ComplianceEntry{},
+	},
+	{
+		Method: fiber.MethodGet, Path: "/headers", Summary: "List the checked headers, their weights and the grade thresholds",
+		Query:    []internal.OpenAPIParameter{{Name: "siteType", Type: "string", Description: "site type preset to apply"}},
+		Response: internal.HeaderReference{},
+		Errors:   []int{fiber.StatusBadRequest},
+	},
+	{
+		Method: fiber.MethodGet, Path: "/metrics", Summary: "Analysis counters in the Prometheus text format",
+		ContentType: "text/plain",
+	},
+	{
+		Method: fiber.MethodGet, Path: "/health", Summary: "Readiness check",
+		Response: map[string]string{},
+	},
+	{
+		Method: fiber.MethodGet, Path: "/openapi.json", Summary: "This OpenAPI document",
+		Response: map[string]any{},
+	},
+	{
+		Method: fiber.MethodGet, Path: "/docs", Summary: "Swagger UI for the OpenAPI document",
+		ContentType: fiber.MIMETextHTML,
+	},
+	{
+		Method: fiber.MethodGet, Path: "/grafana/", Summary: "Grafana JSON datasource connection test",
+	},
+	{
+		Method: fiber.MethodPost, Path: "/grafana/search", Summary: "List the analyzed URLs as Grafana metrics",
+		Response: []string{},
+	},
+	{
+		Method: fiber.MethodPost, Path: "/grafana/query", Summary: "Score history as Grafana time series",
+		Request: internal.GrafanaQuery{}, Response: []any{},
+		Errors: []int{fiber.StatusBadRequest},
+	},
+}
+
+// openAPIHandler serves the OpenAPI document of the API
+func openAPIHandler(c *fiber.Ctx) error {
+	return c.JSON(internal.OpenAPIDocument("HTTP Header Security Analyzer", apiVersion, routePrefix(), ErrorResponse{}, apiOperations))
+}
+
+// docsPage renders the OpenAPI document with Swagger UI
+const docsPage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <title>HTTP Header Security Analyzer API</title>
+  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js"></script>
+  <script>
+    SwaggerUIBundle({ url: "openapi.json", dom_id: "#swagger-ui" });
+  </script>
+</body>
+</html>
+`
+
+// docsHandler serves Swagger UI for the OpenAPI document
+func docsHandler(c *fiber.Ctx) error {
+	c.Set(fiber.HeaderContentType, fiber.MIMETextHTMLCharsetUTF8)
+	return c.SendString(docsPage)
+}